@@ -0,0 +1,209 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+/*
+Busy loops around a select with a default.
+
+	for {
+		select {
+		case ch <- v:
+			return
+		default: // Runs right away when the send can't happen, so the loop spins
+		}
+	}
+
+A default case makes the select return immediately. In a for loop without a condition, the loop then
+spins and burns a CPU until one of the other cases is ready, unless something on the way waits or
+leaves the loop. The default case and the rest of the loop body are searched for a return, a break out
+of the loop, a goto, a channel operation, a select without a default, or a call. Any call may sleep or
+block, except builtins, conversions, sync/atomic and runtime.Gosched, which spin just as much.
+
+Such a default case doesn't protect the sends and receives of the select either, since the select is
+retried until they happen.
+*/
+
+// Gets the position of every select with a default that spins in an unbounded loop.
+func busySelects(pass *analysis.Pass, inspect *inspector.Inspector) map[token.Pos]bool {
+	busy := make(map[token.Pos]bool)
+	inspect.WithStack([]ast.Node{(*ast.SelectStmt)(nil)}, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		sel := node.(*ast.SelectStmt)
+		if defaultClause(sel) == nil {
+			return true
+		}
+
+		// The innermost loop must be a for loop without a condition, in the same function.
+		for i := len(stack) - 2; i >= 0; i-- {
+			switch loop := stack[i].(type) {
+			case *ast.ForStmt:
+				if loop.Cond == nil && !mayWait(pass, loop.Body, sel, true) {
+					busy[sel.Select] = true
+				}
+				return true
+			case *ast.RangeStmt, *ast.FuncLit, *ast.FuncDecl:
+				return true
+			}
+		}
+		return true
+	})
+	return busy
+}
+
+func defaultClause(sel *ast.SelectStmt) *ast.CommClause {
+	for _, clause := range sel.Body.List {
+		if clause := clause.(*ast.CommClause); clause.Comm == nil {
+			return clause
+		}
+	}
+	return nil
+}
+
+/*
+Checks if running the node can wait or leave the loop. For the select being checked, only its default
+case runs when the loop spins. Function literals only run when called, and the call counts already.
+breaksLoop tells if an unlabeled break in the node leaves the loop, which isn't the case inside a
+nested for, switch or select.
+*/
+func mayWait(pass *analysis.Pass, node ast.Node, sel *ast.SelectStmt, breaksLoop bool) bool {
+	waits := false
+	ast.Inspect(node, func(node ast.Node) bool {
+		if waits {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			return false
+
+		case *ast.SelectStmt:
+			if n == sel {
+				waits = mayWait(pass, &ast.BlockStmt{List: defaultClause(n).Body}, nil, false)
+				return false
+			}
+			if defaultClause(n) == nil {
+				waits = true
+				return false
+			}
+			for _, clause := range n.Body.List { // Only the bodies, the cases of a select with a default don't wait.
+				for _, stmt := range clause.(*ast.CommClause).Body {
+					waits = waits || mayWait(pass, stmt, sel, false)
+				}
+			}
+			return false
+
+		case *ast.ReturnStmt, *ast.SendStmt:
+			waits = true
+
+		case *ast.BranchStmt:
+			waits = n.Tok == token.GOTO || n.Label != nil || n.Tok == token.BREAK && breaksLoop
+
+		case *ast.UnaryExpr:
+			waits = n.Op == token.ARROW
+
+		case *ast.ForStmt:
+			waits = mayWaitNested(pass, sel, n.Init, n.Cond, n.Post, n.Body)
+			return false
+
+		case *ast.RangeStmt:
+			if hasTypeInfo(pass) {
+				_, waits = pass.TypesInfo.TypeOf(n.X).Underlying().(*types.Chan)
+			}
+			waits = waits || mayWaitNested(pass, sel, n.X, n.Body)
+			return false
+
+		case *ast.SwitchStmt:
+			waits = mayWaitNested(pass, sel, n.Init, n.Tag, n.Body)
+			return false
+
+		case *ast.TypeSwitchStmt:
+			waits = mayWaitNested(pass, sel, n.Init, n.Assign, n.Body)
+			return false
+
+		case *ast.CallExpr:
+			waits = !spinningCall(pass, n)
+		}
+		return !waits
+	})
+	return waits
+}
+
+// Same as mayWait for the parts of a nested statement, where an unlabeled break only leaves the statement.
+func mayWaitNested(pass *analysis.Pass, sel *ast.SelectStmt, nodes ...ast.Node) bool {
+	for _, node := range nodes {
+		if node != nil && mayWait(pass, node, sel, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the call returns right away: builtins other than panic, conversions, sync/atomic and runtime.Gosched.
+func spinningCall(pass *analysis.Pass, call *ast.CallExpr) bool {
+	if !hasTypeInfo(pass) {
+		switch fun := ast.Unparen(call.Fun).(type) {
+		case *ast.Ident:
+			return types.Universe.Lookup(fun.Name) != nil && fun.Name != "panic"
+		case *ast.SelectorExpr:
+			pkg, ok := fun.X.(*ast.Ident)
+			return ok && (pkg.Name == "atomic" || pkg.Name == "runtime" && fun.Sel.Name == "Gosched")
+		}
+		return false
+	}
+
+	if tv, ok := pass.TypesInfo.Types[call.Fun]; ok && tv.IsType() {
+		return true
+	}
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return false
+	}
+	switch obj := pass.TypesInfo.Uses[id].(type) {
+	case *types.Builtin:
+		return obj.Name() != "panic"
+	case *types.Func:
+		if obj.Pkg() == nil {
+			return false
+		}
+		return obj.Pkg().Path() == "sync/atomic" || obj.Pkg().Path() == "runtime" && obj.Name() == "Gosched"
+	}
+	return false
+}
+
+// Reports selects with a default that spin in an unbounded loop.
+func (l *linter) runBusyLoop(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckBusyLoops {
+		return nil, nil
+	}
+	l.typesLoaded(pass)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	busy := busySelects(pass, inspect)
+	if len(busy) == 0 {
+		return nil, nil
+	}
+	inspect.Preorder([]ast.Node{(*ast.SelectStmt)(nil)}, func(node ast.Node) {
+		n := node.(*ast.SelectStmt)
+		if busy[n.Select] {
+			reportDiagnostic(pass, ruleBusyLoop, analysis.Diagnostic{
+				Pos:     n.Select,
+				End:     n.Body.Lbrace, // Only the select keyword, without the cases
+				Message: "select with a default spins in a loop without a condition - consider waiting, sleeping or leaving the loop in the default case",
+			})
+		}
+	})
+	return nil, nil
+}
@@ -175,6 +175,7 @@ ast.Print(fset, node)
 */
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"go/ast"
@@ -183,9 +184,13 @@ import (
 	"go/token"
 	"go/types"
 	"reflect"
+	"sync"
 
 	"github.com/golangci/plugin-module-register/register"
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
 )
 
 type ChannelCheckPlugin struct {
@@ -197,118 +202,414 @@ type Settings struct {
 	CheckUnbufferedChannels bool   // Enable/disable checking for unbuffered channel creation.
 	CheckBufferAmount       uint64 // The amount that can be in a buffer. 0 means don't do this check.
 	CheckBlockingSends      bool   // Enable/disable checking for blocking sends without default/timeout.
+	CheckDynamicBufferSize  bool   // Enable/disable checking for buffer sizes that aren't constants.
+	CheckBlockingReceives   bool   // Enable/disable checking for blocking receives without default/timeout.
+	CheckSelfDeadlocks      bool   // Enable/disable checking for sends on unbuffered channels that no goroutine can receive.
+	CheckDoubleClose        bool   // Enable/disable checking for channels that may be closed more than once.
+	CheckSendAfterClose     bool   // Enable/disable checking for sends on channels that may already be closed.
+	CheckGoroutineLeaks     bool   // Enable/disable checking for goroutines stranded sending on an abandoned channel.
+	CheckLockedChannelOps   bool   // Enable/disable checking for channel operations that block while a mutex is held.
+	CheckSelectBreak        bool   // Enable/disable checking for breaks in a select that were meant to leave the enclosing loop.
+	CheckBusyLoops          bool   // Enable/disable checking for selects with a default that spin in a loop without a condition.
+	CheckTimerLeaks         bool   // Enable/disable checking for time.After in loops and time.Tick outside of main.
+	CheckTimerStop          bool   // Enable/disable checking for tickers and timers in select cases that aren't stopped.
+	FixBufferSize           uint64 // Buffer size suggested for unbuffered channels whose senders can't be counted. 0 means only suggest counted sizes.
 }
 
-var Analyzer = &analysis.Analyzer{
-	Name:  "channelcheck",
-	Doc:   "reports channel blocking issues",
-	Run:   run,
-	Flags: flagSet,
+/*
+Every rule is its own analyzer, so drivers can enable, disable and report them by name. The AST
+rules walk the shared inspector with a node filter instead of each doing a full traversal, and the
+SSA rules share a single build of the package. The analyzers are named after their rules, like
+blocking_receive, so their flags in the drivers can't be mistaken for the settings flags.
+
+The rules read the settings of the linter instance that created them, so several instances with
+different settings can run in the same process.
+*/
+type linter struct {
+	settings         *Settings
+	reducedPrecision sync.Once // Reports the syntax-only fallback once
+	nolintHandled    bool      // The driver applies nolint directives itself, like golangci-lint
+}
+
+/*
+NewAnalyzers returns one analyzer per rule, in the order they're documented. The settings are read
+when the analyzers run, so they can still be changed by flags registered with RegisterFlags.
+*/
+func NewAnalyzers(settings *Settings) []*analysis.Analyzer {
+	return newAnalyzers(settings, false)
 }
 
-// Flags for the analyzer
-var flagSet flag.FlagSet
+func newAnalyzers(settings *Settings, nolintHandled bool) []*analysis.Analyzer {
+	l := &linter{settings: settings, nolintHandled: nolintHandled}
+	analyzers := []*analysis.Analyzer{
+		{
+			Name:     "blocking_send",
+			Doc:      "reports channel sends without a default, timeout or cancellation case",
+			Run:      l.runBlockingSend,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer, originsAnalyzer},
+		},
+		{
+			Name:     "blocking_receive",
+			Doc:      "reports channel receives and range loops without a default, timeout or cancellation case",
+			Run:      l.runBlockingRecv,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, originsAnalyzer},
+		},
+		{
+			Name:     "unbuffered_make",
+			Doc:      "reports channels created without a buffer size",
+			Run:      l.runUnbufferedMake,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer},
+		},
+		{
+			Name:     "buffer_size",
+			Doc:      "reports channel buffer sizes of 0 or over the configured maximum",
+			Run:      l.runBufferMax,
+			Requires: []*analysis.Analyzer{inspect.Analyzer},
+		},
+		{
+			Name:     "dynamic_buffer",
+			Doc:      "reports channel buffer sizes that are only known at runtime",
+			Run:      l.runDynamicBuffer,
+			Requires: []*analysis.Analyzer{inspect.Analyzer},
+		},
+		{
+			Name:     "self_deadlock",
+			Doc:      "reports sends on unbuffered channels before any goroutine can receive from them",
+			Run:      l.runSelfDeadlock,
+			Requires: []*analysis.Analyzer{inspect.Analyzer},
+		},
+		{
+			Name:     "double_close",
+			Doc:      "reports channels that may be closed more than once",
+			Run:      l.runDoubleClose,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer},
+		},
+		{
+			Name:     "send_after_close",
+			Doc:      "reports sends on channels that may already be closed",
+			Run:      l.runSendAfterClose,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer},
+		},
+		{
+			Name:     "goroutine_leak",
+			Doc:      "reports goroutines stranded sending on a channel that is no longer received from",
+			Run:      l.runGoroutineLeak,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer},
+		},
+		{
+			Name:     "locked_channel_op",
+			Doc:      "reports channel sends, receives and selects without a default done while a mutex is held",
+			Run:      l.runLockedChannelOps,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer},
+		},
+		{
+			Name:     "select_break",
+			Doc:      "reports breaks in a select case that only leave the select instead of the enclosing loop",
+			Run:      l.runSelectBreak,
+			Requires: []*analysis.Analyzer{inspect.Analyzer},
+		},
+		{
+			Name:     "busy_loop",
+			Doc:      "reports selects with a default that spin in a loop without a condition",
+			Run:      l.runBusyLoop,
+			Requires: []*analysis.Analyzer{inspect.Analyzer},
+		},
+		{
+			Name:     "timer_leak",
+			Doc:      "reports time.After in loops and time.Tick outside of main, depending on the Go version",
+			Run:      l.runTimerLeak,
+			Requires: []*analysis.Analyzer{inspect.Analyzer},
+		},
+		{
+			Name:     "unstopped_timer",
+			Doc:      "reports tickers and timers used in select cases that aren't stopped on some return path",
+			Run:      l.runTimerStop,
+			Requires: []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer},
+		},
+	}
+
+	for _, analyzer := range analyzers {
+		analyzer.Run = withSuppressions(analyzer.Name, l.nolintHandled, analyzer.Run)
+		analyzer.RunDespiteErrors = true // Falls back to the syntax, see syntax.go
+		analyzer.Requires = append(analyzer.Requires, suppressAnalyzer)
+	}
+	return analyzers
+}
+
+/*
+NewAnalyzer returns a single analyzer running every rule with its own copy of the settings, for
+drivers that take a single analyzer. The settings are also registered as the analyzer's flags. To
+run several of them in one multichecker, give each one a different Name.
+*/
+func NewAnalyzer(settings Settings) *analysis.Analyzer {
+	rules := NewAnalyzers(&settings)
+	analyzer := &analysis.Analyzer{
+		Name:             "channelcheck",
+		Doc:              "reports channel blocking issues",
+		RunDespiteErrors: true,
+		Requires:         []*analysis.Analyzer{inspect.Analyzer, ssaAnalyzer, originsAnalyzer, suppressAnalyzer},
+		Run: func(pass *analysis.Pass) (interface{}, error) {
+			for _, rule := range rules {
+				if _, err := rule.Run(pass); err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		},
+	}
+	settings.RegisterFlags(&analyzer.Flags)
+	return analyzer
+}
 
-// Global structure to store the variables in
-var settings Settings
+// Analyzer runs every rule with the default settings.
+var Analyzer = NewAnalyzer(DefaultSettings())
+
+// DefaultSettings returns the settings used when nothing is configured.
+func DefaultSettings() Settings {
+	return Settings{
+		CheckBlockingSends:    true,
+		CheckSelfDeadlocks:    true,
+		CheckDoubleClose:      true,
+		CheckSendAfterClose:   true,
+		CheckGoroutineLeaks:   true,
+		CheckLockedChannelOps: true,
+		CheckSelectBreak:      true,
+		CheckBusyLoops:        true,
+		CheckTimerLeaks:       true,
+		CheckTimerStop:        true,
+		FixBufferSize:         1,
+	}
+}
 
+/*
+New decodes the settings from the golangci-lint configuration. Like register.DecodeSettings, but onto
+DefaultSettings, so the checks that are on by default stay on unless the configuration turns them off.
+The settings that the plugin had from the start keep their zero value, as they used to, so existing
+configurations without CheckBlockingSends don't start reporting blocking sends.
+*/
 func New(settings_new any) (register.LinterPlugin, error) {
-	s, err := register.DecodeSettings[Settings](settings_new)
-	if err != nil {
-		return nil, err
+	var buffer bytes.Buffer
+	if err := json.NewEncoder(&buffer).Encode(settings_new); err != nil {
+		return nil, fmt.Errorf("encoding settings: %w", err)
+	}
+
+	decoder := json.NewDecoder(&buffer)
+	decoder.DisallowUnknownFields()
+	s := DefaultSettings()
+	s.CheckBlockingSends = false
+	if err := decoder.Decode(&s); err != nil {
+		return nil, fmt.Errorf("decoding settings: %w", err)
 	}
-	settings.CheckBlockingSends = s.CheckBlockingSends
-	settings.CheckBufferAmount = s.CheckBufferAmount
-	settings.CheckUnbufferedChannels = s.CheckUnbufferedChannels
 
 	return &ChannelCheckPlugin{settings: s}, nil
 }
 
 func (f *ChannelCheckPlugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
-	return []*analysis.Analyzer{
-		{
-			Name:  "channelcheck",
-			Doc:   "reports channel blocking issues",
-			Run:   run,
-			Flags: flagSet,
-		},
-	}, nil
+	return newAnalyzers(&f.settings, true), nil
+}
+
+// RegisterFlags adds the settings as flags, using their current values as the defaults.
+func (s *Settings) RegisterFlags(flagSet *flag.FlagSet) {
+	flagSet.BoolVar(&s.CheckUnbufferedChannels, "unbuffered", s.CheckUnbufferedChannels, "Check for unbuffered channel creation")
+	flagSet.BoolVar(&s.CheckBlockingSends, "blocking", s.CheckBlockingSends, "Check for blocking sends without default/timeout")
+	flagSet.Uint64Var(&s.CheckBufferAmount, "bufferMax", s.CheckBufferAmount, "Check for maximum length of channel buffer being exceeded")
+	flagSet.BoolVar(&s.CheckDynamicBufferSize, "dynamicBuffer", s.CheckDynamicBufferSize, "Check for channel buffer sizes that aren't constants")
+	flagSet.BoolVar(&s.CheckBlockingReceives, "blockingRecv", s.CheckBlockingReceives, "Check for blocking receives without default/timeout")
+	flagSet.BoolVar(&s.CheckSelfDeadlocks, "selfDeadlock", s.CheckSelfDeadlocks, "Check for sends on unbuffered channels before any goroutine can receive")
+	flagSet.BoolVar(&s.CheckDoubleClose, "doubleClose", s.CheckDoubleClose, "Check for channels that may be closed more than once")
+	flagSet.BoolVar(&s.CheckSendAfterClose, "sendAfterClose", s.CheckSendAfterClose, "Check for sends on channels that may already be closed")
+	flagSet.BoolVar(&s.CheckGoroutineLeaks, "goroutineLeak", s.CheckGoroutineLeaks, "Check for goroutines stranded sending on an abandoned channel")
+	flagSet.BoolVar(&s.CheckLockedChannelOps, "lockedChanOp", s.CheckLockedChannelOps, "Check for channel operations that block while a mutex is held")
+	flagSet.BoolVar(&s.CheckSelectBreak, "selectBreak", s.CheckSelectBreak, "Check for breaks in a select that were meant to leave the enclosing loop")
+	flagSet.BoolVar(&s.CheckBusyLoops, "busyLoop", s.CheckBusyLoops, "Check for selects with a default that spin in a loop without a condition")
+	flagSet.BoolVar(&s.CheckTimerLeaks, "timerLeak", s.CheckTimerLeaks, "Check for time.After in loops and time.Tick outside of main")
+	flagSet.BoolVar(&s.CheckTimerStop, "timerStop", s.CheckTimerStop, "Check for tickers and timers in select cases that aren't stopped")
+	flagSet.Uint64Var(&s.FixBufferSize, "fixBufferSize", s.FixBufferSize, "Buffer size suggested for unbuffered channels when the senders can't be counted")
 }
 
 // Initialize the flags from the golangci-lint
 func init() {
-
-	flagSet.BoolVar(&settings.CheckUnbufferedChannels, "unbuffered", false, "Check for unbuffered channel creation")
-	flagSet.BoolVar(&settings.CheckBlockingSends, "blocking", true, "Check for blocking sends without default/timeout")
-	flagSet.Uint64Var(&settings.CheckBufferAmount, "bufferMax", 0, "Check for maximum length of channel buffer being exceeded")
 	register.Plugin("channelcheck", New)
-	return
 }
 
+// The checks need type information. Without it, they fall back to the syntax, see syntax.go.
 func (f *ChannelCheckPlugin) GetLoadMode() string {
-	return register.LoadModeSyntax
+	return register.LoadModeTypesInfo
 }
 
-func run(pass *analysis.Pass) (interface{}, error) {
-
-	for _, file := range pass.Files {
-		var seenPositions = make(map[token.Pos]bool)
-
-		ast.Inspect(file, func(node ast.Node) bool {
-			switch n := node.(type) {
-			// Fails open by design. Will
-			case *ast.SelectStmt: // Select statement for channel matching
+/*
+Collects the sends and receives that are protected by a select with a default, timeout or
+cancellation case. Also returns the unprotected ones in a select whose only way out is the Done of a
+context that's never cancelled, so they can be reported with a specific message.
+*/
+func protectedOps(pass *analysis.Pass, inspect *inspector.Inspector, origins recvOrigins) (map[token.Pos]bool, map[token.Pos]bool) {
+	seenPositions := make(map[token.Pos]bool)
+	uncancellable := make(map[token.Pos]bool)
+	busy := busySelects(pass, inspect)
+	inspect.Preorder([]ast.Node{(*ast.SelectStmt)(nil)}, func(node ast.Node) {
+		n := node.(*ast.SelectStmt)
+		_, defaultOrTimeout, neverCancelled, seenPositionsLocal := processSelect(pass, origins, *n, busy[n.Select])
+		/*
+			If we found a 'SendStmt' or a receive alongside a default or a timer, then it's safe.
+			If NOT found, this case will be covered and added as a linting error.
+		*/
+		if defaultOrTimeout {
+			// Add local Pos to global structure for later
+			for key, value := range seenPositionsLocal {
+				seenPositions[key] = value
+			}
+		} else if neverCancelled {
+			for key := range seenPositionsLocal {
+				uncancellable[key] = true
+			}
+		}
+	})
+	return seenPositions, uncancellable
+}
 
-				if settings.CheckBlockingSends == false {
-					break
-				}
-				channelSendFound, defaultOrTimeout, seenPositionsLocal := processSelect(pass, *n)
-				/*
-					If we found a 'SendStmt' alongside a default or a timer, then it's safe.
-					If NOT found, this case will be covered and added as a linting error.
-				*/
-				if defaultOrTimeout && channelSendFound {
-					// Add local Pos to global structure for later
-					for key, value := range seenPositionsLocal {
-						seenPositions[key] = value
-					}
-				}
+func (l *linter) runBlockingSend(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckBlockingSends {
+		return nil, nil
+	}
+	var sources map[token.Pos][]analysis.RelatedInformation
+	if l.typesLoaded(pass) {
+		sources = sendSources(pass, pass.ResultOf[ssaAnalyzer].(*buildssa.SSA))
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	seenPositions, uncancellable := protectedOps(pass, inspect, pass.ResultOf[originsAnalyzer].(recvOrigins))
 
-			// Most of the work is done in the previous case statement.
-			case *ast.SendStmt:
+	inspect.WithStack([]ast.Node{(*ast.SendStmt)(nil)}, func(node ast.Node, push bool, stack []ast.Node) bool {
+		n := node.(*ast.SendStmt)
+		if !push {
+			return true
+		}
 
-				if settings.CheckBlockingSends == false {
-					break
-				}
-				// If the SendStmt was NOT found within a Select clause, then add a linter error.
-				tokenId := n.Pos()
-				if _, ok := seenPositions[tokenId]; !ok {
-					pass.Reportf(tokenId, "channel send without default or timer - consider adding default or timeout case %q", render(pass.Fset, n))
-				}
+		// If the SendStmt was NOT found within a Select clause, then add a linter error.
+		tokenId := n.Pos()
+		if _, ok := seenPositions[tokenId]; !ok {
+			message := fmt.Sprintf("channel send without default or timer - consider adding default or timeout case %q", render(pass.Fset, n))
+			if uncancellable[tokenId] {
+				message = fmt.Sprintf("channel send in a select whose ctx.Done() case never fires, the context comes from context.Background or context.TODO - consider passing in a cancellable context %q", render(pass.Fset, n))
+			}
+			reportDiagnostic(pass, ruleBlockingSend, analysis.Diagnostic{
+				Pos:            tokenId,
+				End:            n.End(),
+				Message:        message,
+				SuggestedFixes: cancelSendFix(pass, n, stack),
+				Related:        sources[n.Arrow],
+			})
+		}
+		return true
+	})
+	return nil, nil
+}
 
-				return true
-			case *ast.CallExpr:
-				// Channel creation that's unbuffered
-				didCreateChannelWithoutBuffering, bufferAmount := checkChannelCreation(pass, n)
-				if didCreateChannelWithoutBuffering && settings.CheckUnbufferedChannels {
-					pass.Reportf(n.Pos(), "unbuffered channel creation detected - consider specifying buffer size %q", render(pass.Fset, n))
-				}
+func (l *linter) runBlockingRecv(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckBlockingReceives {
+		return nil, nil
+	}
+	l.typesLoaded(pass)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	origins := pass.ResultOf[originsAnalyzer].(recvOrigins)
+	seenPositions, uncancellable := protectedOps(pass, inspect, origins)
+
+	nodeFilter := []ast.Node{(*ast.UnaryExpr)(nil), (*ast.RangeStmt)(nil)}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		switch n := node.(type) {
+		// Same as sends. Receives from timers and contexts are waits that always finish, so they're fine.
+		case *ast.UnaryExpr:
+			if n.Op != token.ARROW {
+				return
+			}
+			if _, ok := seenPositions[n.Pos()]; ok {
+				return
+			}
+			if uncancellable[n.Pos()] || origins[n.OpPos] == originNeverCancel {
+				report(pass, ruleBlockingRecv, n, "channel receive that can only end through a ctx.Done() that never fires, the context comes from context.Background or context.TODO - consider passing in a cancellable context %q", render(pass.Fset, n))
+				return
+			}
+			if isContextDone(pass, n.X) || isTimeoutRecv(pass, origins, n) {
+				return
+			}
+			report(pass, ruleBlockingRecv, n, "channel receive without default or timer - consider adding default or timeout case %q", render(pass.Fset, n))
+
+		// Ranging over a channel blocks until the channel is closed.
+		case *ast.RangeStmt:
+			if isBlockingRange(pass, n) {
+				reportDiagnostic(pass, ruleBlockingRange, analysis.Diagnostic{
+					Pos:     n.Pos(),
+					End:     n.X.End(), // Up to the channel, without the body
+					Message: fmt.Sprintf("range over channel blocks until the channel is closed - consider a select with a timeout or cancellation case %q", render(pass.Fset, n.X)),
+				})
+			}
+		}
+	})
+	return nil, nil
+}
 
-				if settings.CheckBufferAmount > 0 && bufferAmount == -1 {
-					pass.Reportf(n.Pos(), "channel buffer size set to 0 %q", render(pass.Fset, n))
-				} else if settings.CheckBufferAmount > 0 && uint64(bufferAmount) > settings.CheckBufferAmount {
-					pass.Reportf(n.Pos(), "channel buffer size exceeds the specified limit %q", render(pass.Fset, n))
-				}
-				return true
+// Calls 'report' for every channel created with make.
+func inspectChannelCreations(pass *analysis.Pass, report func(call *ast.CallExpr, buffer channelBuffer)) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if isChannel, buffer := checkChannelCreation(pass, call); isChannel {
+			report(call, buffer)
+		}
+	})
+}
 
-			default:
-				return true // Continue traversing for other node types
-			}
+func (l *linter) runUnbufferedMake(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckUnbufferedChannels {
+		return nil, nil
+	}
+	var senders map[token.Pos]int
+	if l.typesLoaded(pass) {
+		senders = goroutineSenders(pass.ResultOf[ssaAnalyzer].(*buildssa.SSA))
+	}
+	inspectChannelCreations(pass, func(n *ast.CallExpr, buffer channelBuffer) {
+		if buffer.omitted {
+			reportDiagnostic(pass, ruleUnbufferedMake, analysis.Diagnostic{
+				Pos:            n.Pos(),
+				End:            n.End(),
+				Message:        fmt.Sprintf("unbuffered channel creation detected - consider specifying buffer size %q", render(pass.Fset, n)),
+				SuggestedFixes: bufferSizeFix(n, senders[n.Lparen], l.settings.FixBufferSize),
+			})
+		}
+	})
+	return nil, nil
+}
 
-			return true
-		})
+func (l *linter) runBufferMax(pass *analysis.Pass) (interface{}, error) {
+	if l.settings.CheckBufferAmount == 0 {
+		return nil, nil
 	}
+	l.typesLoaded(pass)
+	inspectChannelCreations(pass, func(n *ast.CallExpr, buffer channelBuffer) {
+		// The size is only known at runtime, so the limit can't be checked.
+		if buffer.dynamic {
+			return
+		}
+		if !buffer.omitted && buffer.size == 0 {
+			report(pass, ruleZeroBuffer, n, "channel buffer size set to 0 %q", render(pass.Fset, n))
+		} else if buffer.size > l.settings.CheckBufferAmount {
+			report(pass, ruleBufferOverMax, n, "channel buffer size exceeds the specified limit %q", render(pass.Fset, n))
+		}
+	})
+	return nil, nil
+}
 
+func (l *linter) runDynamicBuffer(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckDynamicBufferSize {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil // Named constants can't be told apart from variables.
+	}
+	inspectChannelCreations(pass, func(n *ast.CallExpr, buffer channelBuffer) {
+		if buffer.dynamic {
+			report(pass, ruleDynamicBuffer, n, "dynamic channel buffer size - consider using a constant size %q", render(pass.Fset, n))
+		}
+	})
 	return nil, nil
 }
 
@@ -325,11 +626,12 @@ So, it's impossible to determine whether SELECT statement is okay or not based u
 criteria. As a result, if there's a 'Send' to a channel without fallback cases,
 we must report it.
 */
-func processSelect(pass *analysis.Pass, n ast.SelectStmt) (bool, bool, map[token.Pos]bool) {
+func processSelect(pass *analysis.Pass, origins recvOrigins, n ast.SelectStmt, busy bool) (bool, bool, bool, map[token.Pos]bool) {
 	var seenPositionsLocal = make(map[token.Pos]bool)
 
 	channelSendFound := false
 	defaultOrTimeout := false
+	neverCancelled := false
 	for _, commClause := range n.Body.List { // Iterate through each clause in a select statement
 
 		commClause, ok := commClause.(*ast.CommClause)
@@ -346,69 +648,178 @@ func processSelect(pass *analysis.Pass, n ast.SelectStmt) (bool, bool, map[token
 		}
 
 		if reflect.TypeOf(commClause.Comm) == nil { // 'default' case
+			// A default that spins in a loop retries the select until the send happens, see busyloop.go.
+			defaultOrTimeout = defaultOrTimeout || !busy
+			continue
+		}
+
+		// Receives are protected by the fallback cases just like sends.
+		if recv := recvExpr(commClause.Comm); recv != nil {
+			seenPositionsLocal[recv.Pos()] = true
+		}
+
+		// A context from context.Background or context.TODO has a nil Done channel, so the case never fires.
+		// This is also the case for helpers that return its Done channel.
+		if recv := recvExpr(commClause.Comm); recv != nil && origins[recv.OpPos] == originNeverCancel {
+			neverCancelled = true
+			continue
+		}
+
+		// Cancellation through a context. Once the context is cancelled, the send is abandoned.
+		if recv := recvExpr(commClause.Comm); recv != nil && isContextDone(pass, recv.X) {
 			defaultOrTimeout = true
 			continue
 		}
 
-		// Timeout receive call. If the type being checked is 'time.Time', this is assumed to be a timeout but isn't 100% accurate.
-		foundTimeout := findNodeTimeout(pass, commClause.Comm)
+		// Timeout receive call. The channel is traced back to a timer where possible.
+		foundTimeout := findNodeTimeout(pass, origins, commClause.Comm)
 		if foundTimeout {
 			defaultOrTimeout = true
 		}
 	}
 
-	return channelSendFound, defaultOrTimeout, seenPositionsLocal
+	return channelSendFound, defaultOrTimeout, neverCancelled, seenPositionsLocal
 }
 
-// findNode recursively searches the AST node for a node of the specified type
-// using a depth-first approach.
-func findNodeTimeout(pass *analysis.Pass, node ast.Node) bool {
-
-	foundTimeout := false
-
+// findNodeTimeout checks if the select case receives from a timer, a ticker or a context. The received
+// channel is traced through the SSA form first. If it isn't traced to a timer, such as for parameters,
+// struct fields and make(chan time.Time), any channel carrying 'time.Time' is still accepted.
+func findNodeTimeout(pass *analysis.Pass, origins recvOrigins, node ast.Node) bool {
 	// All channel receives should be 'UnaryExpr' types with an Op of '<-'. Checking for this to reduce computations.
-	node, ok := node.(*ast.ExprStmt)
+	stmt, ok := node.(ast.Stmt)
 	if !ok {
 		return false
 	}
 
 	// Found all receive expressions only
-	nodeExpr, ok := node.(*ast.ExprStmt).X.(*ast.UnaryExpr)
-	if !ok || nodeExpr.Op != token.ARROW {
+	nodeExpr := recvExpr(stmt)
+	if nodeExpr == nil {
 		return false
 	}
 
+	return isTimeoutRecv(pass, origins, nodeExpr)
+}
+
+// isTimeoutRecv checks if the receive is from a timer, a ticker or a context, wherever it's used.
+func isTimeoutRecv(pass *analysis.Pass, origins recvOrigins, recv *ast.UnaryExpr) bool {
+	if origin, ok := origins[recv.OpPos]; ok {
+		switch origin {
+		case originTimer, originCancel:
+			return true
+		case originOther, originNeverCancel: // e.g. a nil channel. Nothing is ever received.
+			return false
+		}
+	}
+
 	// If it's a TICKER type
-	if isTimeReturnType(pass, nodeExpr) {
-		return true
+	return isTimeReturnType(pass, recv)
+}
+
+/*
+Returns the receive expression of a select case, or nil if the case isn't a receive. Handles both
+`case <-c:` and `case v, ok := <-c:`.
+*/
+func recvExpr(stmt ast.Stmt) *ast.UnaryExpr {
+	var expr ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		expr = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) != 1 {
+			return nil
+		}
+		expr = s.Rhs[0]
+	default:
+		return nil
+	}
+
+	unary, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+	if !ok || unary.Op != token.ARROW {
+		return nil
+	}
+	return unary
+}
+
+/*
+Checks if the expression is a call to 'Done()' on a context.Context. The receiver can be anything that
+has a context type: a variable, a struct field (s.ctx.Done()), the result of a method call
+(s.Context().Done()) or a derived context from context.WithCancel and friends.
+*/
+func isContextDone(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return false
+	}
+
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Done" {
+		return false
+	}
+
+	if !hasTypeInfo(pass) {
+		return true // Assume any Done() is a context.
+	}
+	typeOfRecv := pass.TypesInfo.TypeOf(sel.X)
+	if typeOfRecv == nil {
+		return false
 	}
+	return isContextType(typeOfRecv)
+}
 
-	// Need for 'timeAfter' call.
-	// ast.Inspect(nodeExpr, func(n ast.Node) bool {
-	// 	if foundTimeout == true { // Once we've found what we're looking for, short circuit the call
-	// 		return false
-	// 	}
-	// 	if n == nil {
-	// 		return false // Stop traversal at nil nodes
-	// 	}
+/*
+Checks if the type implements context.Context. This is done structurally instead of looking up the
+'context' package because the package isn't guaranteed to be in the import graph of the package
+being analyzed, even though the value is a context.
+*/
+func isContextType(t types.Type) bool {
+	for _, name := range []string{"Deadline", "Done", "Err", "Value"} {
+		obj, _, _ := types.LookupFieldOrMethod(t, true, nil, name)
+		if _, ok := obj.(*types.Func); !ok {
+			return false
+		}
+	}
 
-	// 	call, ok := n.(*ast.CallExpr)
-	// 	if ok {
-	// 		timeoutCall := isTimeAfter(pass, call)
-	// 		if timeoutCall {
-	// 			foundTimeout = true
-	// 			return false
-	// 		}
-	// 	}
+	// Done() must return '<-chan struct{}'
+	done, _, _ := types.LookupFieldOrMethod(t, true, nil, "Done")
+	sig := done.Type().(*types.Signature)
+	if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false
+	}
+	ch, ok := sig.Results().At(0).Type().Underlying().(*types.Chan)
+	if !ok || ch.Dir() != types.RecvOnly {
+		return false
+	}
+	elem, ok := ch.Elem().Underlying().(*types.Struct)
+	return ok && elem.NumFields() == 0
+}
 
-	// 	return true // Continue traversal to child nodes
-	// })
+/*
+Checks if the range statement is over a channel. Tickers are skipped since looping over 'ticker.C'
+forever is intended.
+*/
+func isBlockingRange(pass *analysis.Pass, n *ast.RangeStmt) bool {
+	if !hasTypeInfo(pass) {
+		return false // Can't tell a channel from a slice.
+	}
+	typeOfX := pass.TypesInfo.TypeOf(n.X)
+	if typeOfX == nil {
+		return false
+	}
 
-	return foundTimeout
+	ch, ok := typeOfX.Underlying().(*types.Chan)
+	if !ok {
+		return false
+	}
+	return ch.Elem().String() != "time.Time"
 }
 
 // Is this too strict? Could be?
 func isTimeAfter(pass *analysis.Pass, expr ast.Expr) bool {
+	return isTimeCall(pass, expr, "After")
+}
+
+// Checks if the expression calls the function of the "time" package with the name, like time.After.
+func isTimeCall(pass *analysis.Pass, expr ast.Expr, name string) bool {
 	call, ok := expr.(*ast.CallExpr)
 	if !ok {
 		return false
@@ -420,7 +831,7 @@ func isTimeAfter(pass *analysis.Pass, expr ast.Expr) bool {
 	}
 
 	// 1. Check the selector name
-	if sel.Sel.Name != "After" {
+	if sel.Sel.Name != name {
 		return false
 	}
 
@@ -431,6 +842,9 @@ func isTimeAfter(pass *analysis.Pass, expr ast.Expr) bool {
 	}
 
 	// 3. Use type information to verify it's the "time" package.
+	if !hasTypeInfo(pass) {
+		return id.Name == "time"
+	}
 	obj := pass.TypesInfo.Uses[id]
 	if obj == nil {
 		return false // Identifier not found in type info
@@ -455,6 +869,9 @@ NOTE: This IS prone to false negatives if there is another channel sending time.
 This is such a great way to do this I'm okay with this false negative though.
 */
 func isTimeReturnType(pass *analysis.Pass, expr ast.Expr) bool {
+	if !hasTypeInfo(pass) {
+		return timerRecvSyntax(pass, expr)
+	}
 	typeOfExpr := pass.TypesInfo.TypeOf(expr) // time.Time is interesting here
 	if typeOfExpr == nil {
 		return false // Or report an error
@@ -467,54 +884,70 @@ func isTimeReturnType(pass *analysis.Pass, expr ast.Expr) bool {
 	return false
 }
 
-func checkChannelCreation(pass *analysis.Pass, node *ast.CallExpr) (bool, int64) {
+// The buffer of a channel created with make(chan T, size)
+type channelBuffer struct {
+	size    uint64 // Evaluated buffer size. Only set when the size is a constant.
+	omitted bool   // No size argument at all: make(chan T)
+	dynamic bool   // The size isn't a constant, so it's only known at runtime.
+}
+
+/*
+Checks if the call is a channel creation with make and evaluates its buffer. The channel type is
+resolved through the type information, so named channel types like 'type Queue chan int' work too.
+*/
+func checkChannelCreation(pass *analysis.Pass, node *ast.CallExpr) (bool, channelBuffer) {
 	fun, ok := node.Fun.(*ast.Ident)
-	if !ok || fun == nil || fun.Name != "make" {
-		return false, 0
+	if !ok || fun == nil || fun.Name != "make" || len(node.Args) == 0 {
+		return false, channelBuffer{}
+	}
+	if !hasTypeInfo(pass) {
+		return channelCreationSyntax(node)
+	}
+	if _, ok := pass.TypesInfo.Uses[fun].(*types.Builtin); !ok {
+		return false, channelBuffer{} // Shadowed 'make'
 	}
 
-	if len(node.Args) > 0 {
-		if _, ok := node.Args[0].(*ast.ChanType); ok { // It's a channel
-			if len(node.Args) == 1 {
-				return true, 0 // Unbuffered channel
-			}
-
-			if len(node.Args) == 2 {
-				// Evaluate the buffer size expression
-				bufferSize, err := evalBufferSize(pass, node.Args[1])
-				if err != nil {
-					return true, 0 // Or another error indicator
-				}
+	chanType := pass.TypesInfo.TypeOf(node.Args[0])
+	if chanType == nil {
+		return false, channelBuffer{}
+	}
+	if _, ok := chanType.Underlying().(*types.Chan); !ok {
+		return false, channelBuffer{}
+	}
 
-				if bufferSize == 0 {
-					return false, -1
-				}
-				return false, int64(bufferSize)
-			}
-		}
+	if len(node.Args) == 1 {
+		return true, channelBuffer{omitted: true} // Unbuffered channel
 	}
 
-	return false, 0
+	// Evaluate the buffer size expression
+	bufferSize, err := evalBufferSize(pass, node.Args[1])
+	if err != nil {
+		return true, channelBuffer{dynamic: true}
+	}
+	return true, channelBuffer{size: bufferSize}
 }
 
 /*
-Only supports a literal in the buffer size slot. Could expand to more complicated cases.
+Evaluates the buffer size using the constant value computed by the type checker. This handles
+literals, named constants like 'const QueueSize = 1024' and constant expressions like '2*N'.
+Anything else, such as a variable, returns an error since it's only known at runtime.
 */
 func evalBufferSize(pass *analysis.Pass, expr ast.Expr) (uint64, error) {
-	// 1. Check for integer literal (BasicLit)
-	if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.INT {
-		val := constant.ToInt(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
-		if val.Kind() == constant.Int {
-			bufferSize, exact := constant.Uint64Val(val)
-			if !exact {
-				return 0, fmt.Errorf("buffer size is too large")
-			}
-			return bufferSize, nil
-		} else {
-			return 0, fmt.Errorf("invalid buffer size type: %v", val.Kind()) // More specific error
-		}
+	tv, ok := pass.TypesInfo.Types[expr]
+	if !ok || tv.Value == nil {
+		return 0, fmt.Errorf("buffer size is not a constant expression")
+	}
+
+	val := constant.ToInt(tv.Value)
+	if val.Kind() != constant.Int {
+		return 0, fmt.Errorf("invalid buffer size type: %v", val.Kind()) // More specific error
+	}
+
+	bufferSize, exact := constant.Uint64Val(val)
+	if !exact {
+		return 0, fmt.Errorf("buffer size is too large")
 	}
-	return 0, fmt.Errorf("invalid buffer size expression")
+	return bufferSize, nil
 }
 
 // render returns the pretty-print of the given node
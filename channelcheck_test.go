@@ -0,0 +1,23 @@
+package channelcheck_test
+
+import (
+	"testing"
+
+	channelcheck "github.com/asymmetric-research/channel_linter"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+/*
+Runs every rule over the examples and checks the findings against their '// want' comments. The rules
+that are off by default are turned on, and the buffer limit is set, so every example is covered.
+*/
+func TestExamples(t *testing.T) {
+	settings := channelcheck.DefaultSettings()
+	settings.CheckUnbufferedChannels = true
+	settings.CheckBlockingReceives = true
+	settings.CheckDynamicBufferSize = true
+	settings.CheckBufferAmount = 1000
+
+	// The module is the test directory, so the examples load with their real import paths.
+	analysistest.Run(t, ".", channelcheck.NewAnalyzer(settings), "./examples/...")
+}
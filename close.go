@@ -0,0 +1,443 @@
+package channelcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/ssa"
+)
+
+/*
+Close tracking through the SSA control flow graph.
+
+Closing a channel twice panics, and so does sending on a closed channel.
+
+Within a single function, the same channel may be closed twice if:
+- close is called in a loop
+- close is both deferred and called in the body
+- close is called in two goroutines, or in a goroutine started in a loop, without a sync.Once
+
+All of these are found the same way. Every place the channel is closed in a function is a "close site":
+a call, a defer or a 'go' statement whose function literal closes the channel. If one close site can
+be executed after another one, or after itself, the channel is closed twice on that path.
+
+Channels in variables and fields are identified by their address, so a new channel stored there in
+between, like 'close(s.done); s.done = make(chan struct{})', ends the path. The same goes for sends
+after a close.
+
+Closes inside a sync.Once are never close sites since they're in a function literal that's only
+called by the Once.
+
+The paths don't follow branch conditions, except for the common guard that remembers the close:
+
+	if !closed {
+		close(c)
+		closed = true
+	}
+
+A close in the body of an if whose condition reads a variable or field assigned in that body isn't
+reported, unless it runs in a goroutine, where the guard is a data race.
+*/
+
+// A place in a function where a channel is closed or sent on.
+type chanSite struct {
+	instr ssa.Instruction // The instruction in the function, or the go statement that started the goroutine
+	key   any             // Identity of the channel, from chanKey
+	pos   token.Pos       // Position of the '(' of the close call or the '<-' of the send
+}
+
+// Identity of a struct field holding a channel, e.g. 's.done'.
+type fieldKey struct {
+	base  any
+	field int
+}
+
+/*
+Gets the identity of a channel value. Two values with the same key are the same channel. Loads of a
+variable, global or struct field return the address instead of the loaded value, since every load is
+a different SSA value.
+*/
+func chanKey(value ssa.Value) any {
+	switch v := value.(type) {
+	case *ssa.ChangeType: // chan T to chan<- T
+		return chanKey(v.X)
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return v
+		}
+		switch addr := v.X.(type) {
+		case *ssa.Alloc, *ssa.Global, *ssa.FreeVar:
+			return addr
+		case *ssa.FieldAddr:
+			return fieldKey{base: chanKey(addr.X), field: addr.Field}
+		}
+	}
+	return value
+}
+
+// Gets the identity of the channel stored at the address, matching chanKey of a load from it.
+func addrKey(addr ssa.Value) any {
+	if field, ok := addr.(*ssa.FieldAddr); ok {
+		return fieldKey{base: chanKey(field.X), field: field.Field}
+	}
+	return chanKey(addr)
+}
+
+// Gets the channel argument if the call is the 'close' builtin.
+func closedChannel(call *ssa.CallCommon) (ssa.Value, bool) {
+	builtin, ok := call.Value.(*ssa.Builtin)
+	if !ok || builtin.Name() != "close" || len(call.Args) != 1 {
+		return nil, false
+	}
+	return call.Args[0], true
+}
+
+/*
+Collects the channel operations matched by 'ops' in the function. Operations in goroutines started by
+the function count too, as long as the channel is captured from the function. Their instruction is
+the 'go' statement.
+*/
+func collectSites(fn *ssa.Function, ops func(instr ssa.Instruction) []chanSite) []chanSite {
+	var sites []chanSite
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			sites = append(sites, ops(instr)...)
+
+			goInstr, ok := instr.(*ssa.Go)
+			if !ok {
+				continue
+			}
+			closure, ok := goInstr.Call.Value.(*ssa.MakeClosure)
+			if !ok {
+				continue
+			}
+			for _, site := range collectSites(closure.Fn.(*ssa.Function), ops) {
+				if key, ok := boundKey(closure, site.key); ok {
+					sites = append(sites, chanSite{instr: goInstr, key: key, pos: site.pos})
+				}
+			}
+		}
+	}
+	return sites
+}
+
+// Matches 'close(c)', 'defer close(c)' and 'go close(c)'.
+func closeOps(instr ssa.Instruction) []chanSite {
+	call, ok := instr.(ssa.CallInstruction)
+	if !ok {
+		return nil
+	}
+	if ch, ok := closedChannel(call.Common()); ok {
+		return []chanSite{{instr: instr, key: chanKey(ch), pos: call.Common().Pos()}}
+	}
+	return nil
+}
+
+// Matches 'c <- v', both on its own and as a select case.
+func sendOps(instr ssa.Instruction) []chanSite {
+	switch instr := instr.(type) {
+	case *ssa.Send:
+		return []chanSite{{instr: instr, key: chanKey(instr.Chan), pos: instr.Pos()}}
+	case *ssa.Select:
+		var sites []chanSite
+		for _, state := range instr.States {
+			if state.Dir == types.SendOnly {
+				sites = append(sites, chanSite{instr: instr, key: chanKey(state.Chan), pos: state.Pos})
+			}
+		}
+		return sites
+	}
+	return nil
+}
+
+/*
+Translates the key of a channel inside a function literal to the key in the function that created
+the closure. Only channels captured from the enclosing function can be translated.
+*/
+func boundKey(closure *ssa.MakeClosure, key any) (any, bool) {
+	switch k := key.(type) {
+	case *ssa.FreeVar:
+		for i, freeVar := range closure.Fn.(*ssa.Function).FreeVars {
+			if freeVar == k {
+				return chanKey(closure.Bindings[i]), true
+			}
+		}
+	case fieldKey:
+		if base, ok := boundKey(closure, k.base); ok {
+			return fieldKey{base: base, field: k.field}, true
+		}
+	}
+	return nil, false
+}
+
+/*
+Finds sends that can happen after the channel was closed in the same function, which panics. This
+includes sends in goroutines started after the close. Deferred closes run when the function returns,
+and closes in other goroutines race with the send, so only direct calls to close count.
+
+Returns the position of the '<-' of every such send, mapped to the position of the close.
+*/
+func sendsAfterClose(ssaInfo *buildssa.SSA) map[token.Pos]token.Pos {
+	closedSends := make(map[token.Pos]token.Pos)
+	for _, fn := range ssaInfo.SrcFuncs {
+		sends := collectSites(fn, sendOps)
+		for _, closeSite := range collectSites(fn, closeOps) {
+			if _, ok := closeSite.instr.(*ssa.Call); !ok {
+				continue
+			}
+			for _, send := range sends {
+				if send.key == closeSite.key && reachesWithoutStore(closeSite.instr, send.instr, send.key) {
+					closedSends[send.pos] = closeSite.pos
+				}
+			}
+		}
+	}
+	return closedSends
+}
+
+// Reports sends that can happen after the channel was closed. Sending on a closed channel panics, whether or not it's in a select.
+func (l *linter) runSendAfterClose(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckSendAfterClose {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil
+	}
+	closedSends := sendsAfterClose(pass.ResultOf[ssaAnalyzer].(*buildssa.SSA))
+	if len(closedSends) == 0 {
+		return nil, nil
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.SendStmt)(nil)}, func(node ast.Node) {
+		n := node.(*ast.SendStmt)
+		if closePos, ok := closedSends[n.Arrow]; ok {
+			report(pass, ruleSendAfterClose, n, "send on channel that may already be closed, closed on line %d %q", pass.Fset.Position(closePos).Line, render(pass.Fset, n))
+		}
+	})
+	return nil, nil
+}
+
+// Reports channels that may be closed more than once in every function of the package.
+func (l *linter) runDoubleClose(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckDoubleClose {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil
+	}
+	ssaInfo := pass.ResultOf[ssaAnalyzer].(*buildssa.SSA)
+	closeCalls := builtinCalls(pass, "close")
+	guarded := guardedCalls(pass, closeCalls)
+
+	for _, fn := range ssaInfo.SrcFuncs {
+		var sites []chanSite
+		for _, site := range collectSites(fn, closeOps) {
+			if _, inGoroutine := site.instr.(*ssa.Go); inGoroutine || !guarded[site.pos] {
+				sites = append(sites, site)
+			}
+		}
+		reported := make(map[token.Pos]bool)
+
+		for i, site := range sites {
+			// Closed in a loop. The channel must be the same one on every iteration though.
+			if reachesWithoutStore(site.instr, site.instr, site.key) && !definedInLoop(site.key, site.instr.Block()) {
+				reportDoubleClose(pass, closeCalls, reported, site.pos, "channel closed in a loop may be closed more than once")
+			}
+
+			for _, other := range sites[i+1:] {
+				if other.key != site.key || other.pos == site.pos {
+					continue
+				}
+				if reachesWithoutStore(site.instr, other.instr, site.key) || reachesWithoutStore(other.instr, site.instr, site.key) {
+					line := pass.Fset.Position(site.pos).Line
+					reportDoubleClose(pass, closeCalls, reported, other.pos, fmt.Sprintf("channel may be closed more than once, also closed on line %d", line))
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func reportDoubleClose(pass *analysis.Pass, closeCalls map[token.Pos]*ast.CallExpr, reported map[token.Pos]bool, pos token.Pos, message string) {
+	if reported[pos] {
+		return
+	}
+	reported[pos] = true
+
+	call, ok := closeCalls[pos]
+	if !ok {
+		return
+	}
+	report(pass, ruleDoubleClose, call, "%s %q", message, render(pass.Fset, call))
+}
+
+/*
+Gets the calls that are in the body of an if statement whose condition reads a variable or field that
+the body assigns, like a 'closed' flag. Keyed by the position of the '(' like the calls.
+*/
+func guardedCalls(pass *analysis.Pass, calls map[token.Pos]*ast.CallExpr) map[token.Pos]bool {
+	guarded := make(map[token.Pos]bool)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.WithStack([]ast.Node{(*ast.IfStmt)(nil)}, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		n := node.(*ast.IfStmt)
+
+		read := make(map[string]bool) // Rendered variables and fields in the condition
+		ast.Inspect(n.Cond, func(node ast.Node) bool {
+			switch x := node.(type) {
+			case *ast.Ident, *ast.SelectorExpr:
+				read[render(pass.Fset, x)] = true
+			case *ast.CallExpr:
+				return false
+			}
+			return true
+		})
+
+		assigned := false
+		ast.Inspect(n.Body, func(node ast.Node) bool {
+			switch x := node.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				for _, lhs := range x.Lhs {
+					assigned = assigned || read[render(pass.Fset, ast.Unparen(lhs))]
+				}
+			}
+			return !assigned
+		})
+		if !assigned {
+			return true
+		}
+
+		ast.Inspect(n.Body, func(node ast.Node) bool {
+			if call, ok := node.(*ast.CallExpr); ok && calls[call.Lparen] == call {
+				guarded[call.Lparen] = true
+			}
+			return true
+		})
+		return true
+	})
+	return guarded
+}
+
+/*
+Maps the position of the '(' of every call to the builtin to the call. SSA instructions only keep the
+position of the '(' so this gets the AST node back for reporting.
+*/
+func builtinCalls(pass *analysis.Pass, name string) map[token.Pos]*ast.CallExpr {
+	calls := make(map[token.Pos]*ast.CallExpr)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if fun, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+			if builtin, ok := pass.TypesInfo.Uses[fun].(*types.Builtin); ok && builtin.Name() == name {
+				calls[call.Lparen] = call
+			}
+		}
+	})
+	return calls
+}
+
+// Checks if 'to' can be executed after 'from' within the same call of the function.
+func reaches(from, to ssa.Instruction) bool {
+	if from.Block() == to.Block() && instrIndex(from) < instrIndex(to) {
+		return true
+	}
+	return blockReaches(from.Block().Succs, to.Block())
+}
+
+/*
+Checks if 'to' can be executed after 'from' without a store to the address of the channel in
+between. Such a store puts another channel there, so 'to' doesn't operate on the same one.
+*/
+func reachesWithoutStore(from, to ssa.Instruction, key any) bool {
+	// Scans the instructions, returning whether 'to' was found and whether the path goes on.
+	scan := func(instrs []ssa.Instruction) (bool, bool) {
+		for _, instr := range instrs {
+			if instr == to {
+				return true, false
+			}
+			if store, ok := instr.(*ssa.Store); ok && addrKey(store.Addr) == key {
+				return false, false
+			}
+		}
+		return false, true
+	}
+
+	found, next := scan(from.Block().Instrs[instrIndex(from)+1:])
+	if found || !next {
+		return found
+	}
+	seen := make(map[*ssa.BasicBlock]bool)
+	stack := append([]*ssa.BasicBlock(nil), from.Block().Succs...)
+	for len(stack) > 0 {
+		block := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[block] {
+			continue
+		}
+		seen[block] = true
+		found, next := scan(block.Instrs)
+		if found {
+			return true
+		}
+		if next {
+			stack = append(stack, block.Succs...)
+		}
+	}
+	return false
+}
+
+// Checks if the target block can be reached from any of the starting blocks.
+func blockReaches(start []*ssa.BasicBlock, target *ssa.BasicBlock) bool {
+	seen := make(map[*ssa.BasicBlock]bool)
+	stack := append([]*ssa.BasicBlock(nil), start...)
+	for len(stack) > 0 {
+		block := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if block == target {
+			return true
+		}
+		if seen[block] {
+			continue
+		}
+		seen[block] = true
+		stack = append(stack, block.Succs...)
+	}
+	return false
+}
+
+func instrIndex(instr ssa.Instruction) int {
+	for i, other := range instr.Block().Instrs {
+		if other == instr {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+Checks if the channel is created again on every iteration of the loop containing the block. For
+example, 'c := make(chan int)' inside the loop body is a new channel each time.
+*/
+func definedInLoop(key any, loop *ssa.BasicBlock) bool {
+	if field, ok := key.(fieldKey); ok {
+		return definedInLoop(field.base, loop)
+	}
+
+	instr, ok := key.(ssa.Instruction)
+	if !ok || instr.Block() == nil {
+		return false // Parameters, globals and free variables
+	}
+	def := instr.Block()
+	return (def == loop || blockReaches(loop.Succs, def)) && blockReaches(def.Succs, loop)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+/*
+Baseline of findings that already exist, so the linter can be adopted on a large codebase and only
+report new findings.
+
+A finding is identified by its rule, package, function and the normalized source of the reported
+node instead of its line, so the baseline survives unrelated edits to the file. The same node can be
+reported more than once in a function, like two identical sends, so the baseline keeps a count.
+*/
+
+// Identity of a finding.
+type fingerprint struct {
+	Rule     string `json:"rule"`
+	Package  string `json:"package"`
+	Function string `json:"function"`
+	Node     string `json:"node"`
+}
+
+type baselineEntry struct {
+	fingerprint
+	Count int `json:"count"`
+}
+
+type baseline struct {
+	Findings []baselineEntry `json:"findings"`
+}
+
+func packageFile(pkg *packages.Package, pos token.Pos) *ast.File {
+	for _, file := range pkg.Syntax {
+		if file.FileStart <= pos && pos <= file.FileEnd {
+			return file
+		}
+	}
+	return nil
+}
+
+// Gets the name of the function declaration containing the position, like 'run' or '(*worker).run'.
+func enclosingFunction(pkg *packages.Package, pos token.Pos) string {
+	file := packageFile(pkg, pos)
+	if file == nil {
+		return ""
+	}
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || pos < fn.Pos() || pos >= fn.End() {
+			continue
+		}
+		if fn.Recv == nil || len(fn.Recv.List) == 0 {
+			return fn.Name.Name
+		}
+		return fmt.Sprintf("(%s).%s", exprString(pkg.Fset, fn.Recv.List[0].Type), fn.Name.Name)
+	}
+	return ""
+}
+
+func exprString(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fset, expr)
+	return buf.String()
+}
+
+/*
+Gets the normalized source of the reported node. A node that matches the reported range exactly is
+printed the same way the messages render it. Otherwise, like for the header of a range loop, the
+source is used with its whitespace collapsed.
+*/
+func reportedNode(pkg *packages.Package, diag analysis.Diagnostic, sources map[string][]byte) string {
+	file := packageFile(pkg, diag.Pos)
+	if file == nil || !diag.End.IsValid() {
+		return diag.Message
+	}
+
+	path, _ := astutil.PathEnclosingInterval(file, diag.Pos, diag.End)
+	if len(path) > 0 && path[0].Pos() == diag.Pos && path[0].End() == diag.End {
+		var buf bytes.Buffer
+		if err := printer.Fprint(&buf, pkg.Fset, path[0]); err == nil {
+			return strings.Join(strings.Fields(buf.String()), " ")
+		}
+	}
+
+	text := sourceText(pkg.Fset, diag.Pos, diag.End, sources)
+	if text == "" {
+		return diag.Message
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Drops the findings that are in the baseline.
+func newFindings(findings []finding, path string) ([]finding, error) {
+	known, err := readBaselineFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var result []finding
+	for _, f := range findings {
+		if known[f.fingerprint] > 0 {
+			known[f.fingerprint]--
+			continue
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+func writeBaselineFile(path string, findings []finding) error {
+	counts := make(map[fingerprint]int)
+	for _, f := range findings {
+		counts[f.fingerprint]++
+	}
+
+	var b baseline
+	for fp, count := range counts {
+		b.Findings = append(b.Findings, baselineEntry{fingerprint: fp, Count: count})
+	}
+	sort.Slice(b.Findings, func(i, j int) bool {
+		x, y := b.Findings[i], b.Findings[j]
+		if x.Package != y.Package {
+			return x.Package < y.Package
+		}
+		if x.Function != y.Function {
+			return x.Function < y.Function
+		}
+		if x.Rule != y.Rule {
+			return x.Rule < y.Rule
+		}
+		return x.Node < y.Node
+	})
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false) // Keep '<-' readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(b); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func readBaselineFile(path string) (map[fingerprint]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("reading baseline %s: %v", path, err)
+	}
+
+	known := make(map[fingerprint]int)
+	for _, entry := range b.Findings {
+		known[entry.fingerprint] += entry.Count
+	}
+	return known, nil
+}
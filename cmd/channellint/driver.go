@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// A diagnostic of the run with its fingerprint.
+type finding struct {
+	fingerprint
+	diag analysis.Diagnostic
+	fset *token.FileSet
+	posn token.Position
+}
+
+// Output options that need every finding of the run, which the standard drivers don't give access to.
+type options struct {
+	baseline      string // Baseline file, see baseline.go
+	writeBaseline bool
+	format        string // 'text' or 'sarif'
+	tests         bool   // Also analyze the test files, like -test of the standard driver
+}
+
+// Runs the analyzers and prints the findings. Returns the exit code.
+func run(analyzers []*analysis.Analyzer, patterns []string, opts options) int {
+	findings, failed, err := analyze(analyzers, patterns, opts.tests)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if opts.writeBaseline {
+		if err := writeBaselineFile(opts.baseline, findings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "wrote %d findings to %s\n", len(findings), opts.baseline)
+		return 0
+	}
+	if opts.baseline != "" {
+		if findings, err = newFindings(findings, opts.baseline); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	switch opts.format {
+	case "sarif":
+		if err := writeSARIF(os.Stdout, findings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	default:
+		for _, f := range findings {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", f.posn, f.diag.Message)
+		}
+	}
+
+	if len(findings) > 0 {
+		return 3 // Same as the other drivers when there are diagnostics
+	}
+	if failed {
+		return 1 // Some packages couldn't be loaded or analyzed
+	}
+	return 0
+}
+
+/*
+Loads and analyzes the packages, returning the findings sorted by position. Like the standard driver,
+errors of a package are printed and the findings of the other packages are still returned, with
+failed set.
+*/
+func analyze(analyzers []*analysis.Analyzer, patterns []string, tests bool) ([]finding, bool, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.LoadAllSyntax, Tests: tests}, patterns...)
+	if err != nil {
+		return nil, false, err
+	}
+	failed := packages.PrintErrors(pkgs) > 0
+	graph, err := checker.Analyze(analyzers, pkgs, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	type key struct {
+		posn, end token.Position
+		analyzer  *analysis.Analyzer
+		message   string
+	}
+	seen := make(map[key]bool) // Packages are analyzed again with their tests, same as the standard driver
+	sources := make(map[string][]byte)
+
+	var findings []finding
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", act, act.Err)
+			failed = true
+			continue
+		}
+		for _, diag := range act.Diagnostics {
+			posn := act.Package.Fset.Position(diag.Pos)
+			k := key{posn, act.Package.Fset.Position(diag.End), act.Analyzer, diag.Message}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+
+			findings = append(findings, finding{
+				fingerprint: fingerprint{
+					Rule:     diag.Category, // The rule ID
+					Package:  act.Package.PkgPath,
+					Function: enclosingFunction(act.Package, diag.Pos),
+					Node:     reportedNode(act.Package, diag, sources),
+				},
+				diag: diag,
+				fset: act.Package.Fset,
+				posn: posn,
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i].posn, findings[j].posn
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return findings, failed, nil
+}
+
+// Gets the source between the positions.
+func sourceText(fset *token.FileSet, pos, end token.Pos, sources map[string][]byte) string {
+	if !pos.IsValid() || !end.IsValid() {
+		return ""
+	}
+	tokenFile := fset.File(pos)
+	src := fileSource(tokenFile.Name(), sources)
+	start, stop := tokenFile.Offset(pos), tokenFile.Offset(end)
+	if stop > len(src) || start > stop {
+		return ""
+	}
+	return string(src[start:stop])
+}
+
+// Gets the contents of the file. The files are read once and kept in 'sources'.
+func fileSource(filename string, sources map[string][]byte) []byte {
+	src, ok := sources[filename]
+	if !ok {
+		src, _ = os.ReadFile(filename)
+		sources[filename] = src
+	}
+	return src
+}
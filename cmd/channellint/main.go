@@ -1,11 +1,163 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
 	channelcheck "github.com/asymmetric-research/channel_linter"
 
-	"golang.org/x/tools/go/analysis/singlechecker"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/multichecker"
 )
 
 func main() {
-	singlechecker.Main(channelcheck.Analyzer)
+	settings := channelcheck.DefaultSettings()
+	settings.RegisterFlags(flag.CommandLine)
+	baselinePath := flag.String("baseline", "", "Only report findings that aren't in this baseline file")
+	writeBaseline := flag.Bool("write-baseline", false, "Write the current findings to the -baseline file instead of reporting them")
+	format := flag.String("format", "text", "Output format of the findings: text or sarif")
+	explain := flag.String("explain", "", "Print the rationale and fix guidance of a rule, like CC001, then exit")
+	analyzers := channelcheck.NewAnalyzers(&settings)
+
+	if hasFlag(os.Args[1:], "explain") {
+		flag.Parse()
+		explainRule(*explain)
+		return
+	}
+
+	enableSelected(&settings, os.Args[1:])
+
+	// These options need every finding of the run, so they have their own driver.
+	if !hasFlag(os.Args[1:], "baseline", "write-baseline", "format") {
+		multichecker.Main(analyzers...)
+	}
+
+	for _, name := range []string{"fix", "diff", "json", "c"} {
+		if hasFlag(os.Args[1:], name) {
+			fail(fmt.Sprintf("-%s can't be combined with -baseline, -write-baseline or -format", name))
+		}
+	}
+	selected := make(map[string]*bool)
+	for _, analyzer := range analyzers {
+		selected[analyzer.Name] = flag.Bool(analyzer.Name, false, "enable "+analyzer.Name+" analysis")
+	}
+	tests := flag.Bool("test", true, "indicates whether test files should be analyzed, too")
+
+	flag.Parse()
+	if *writeBaseline && *baselinePath == "" {
+		fail("-write-baseline needs a -baseline file to write")
+	}
+	if *format != "text" && *format != "sarif" {
+		fail("-format must be text or sarif")
+	}
+	opts := options{baseline: *baselinePath, writeBaseline: *writeBaseline, format: *format, tests: *tests}
+	os.Exit(run(selectAnalyzers(analyzers, selected), flag.Args(), opts))
+}
+
+/*
+Picks the analyzers to run from the rule name flags, the same way as the standard driver: if any is
+set to true, only those run. Otherwise, the ones set to false are skipped.
+*/
+func selectAnalyzers(analyzers []*analysis.Analyzer, selected map[string]*bool) []*analysis.Analyzer {
+	set := make(map[string]bool)
+	anyEnabled := false
+	flag.Visit(func(f *flag.Flag) {
+		if enabled, ok := selected[f.Name]; ok {
+			set[f.Name] = true
+			anyEnabled = anyEnabled || *enabled
+		}
+	})
+
+	var result []*analysis.Analyzer
+	for _, analyzer := range analyzers {
+		enabled := *selected[analyzer.Name]
+		if anyEnabled && enabled || !anyEnabled && !(set[analyzer.Name] && !enabled) {
+			result = append(result, analyzer)
+		}
+	}
+	return result
+}
+
+// Checks if any of the flags is set, before the flags are parsed.
+func hasFlag(args []string, names ...string) bool {
+	for _, arg := range args {
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return false // Flags end at the first package pattern
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if slices.Contains(names, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets whether a boolean flag is set to true, before the flags are parsed. '-name' alone means true.
+func boolFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return false
+		}
+		flagName, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if flagName != name {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		return !hasValue || err == nil && enabled
+	}
+	return false
+}
+
+/*
+Turns on the settings of the rules selected by name, like '-unbuffered_make', so rules that are off by
+default don't need their settings flag as well.
+*/
+func enableSelected(settings *channelcheck.Settings, args []string) {
+	toggles := map[string]*bool{
+		"blocking_send":     &settings.CheckBlockingSends,
+		"blocking_receive":  &settings.CheckBlockingReceives,
+		"unbuffered_make":   &settings.CheckUnbufferedChannels,
+		"dynamic_buffer":    &settings.CheckDynamicBufferSize,
+		"self_deadlock":     &settings.CheckSelfDeadlocks,
+		"double_close":      &settings.CheckDoubleClose,
+		"send_after_close":  &settings.CheckSendAfterClose,
+		"goroutine_leak":    &settings.CheckGoroutineLeaks,
+		"locked_channel_op": &settings.CheckLockedChannelOps,
+		"select_break":      &settings.CheckSelectBreak,
+		"busy_loop":         &settings.CheckBusyLoops,
+		"timer_leak":        &settings.CheckTimerLeaks,
+		"unstopped_timer":   &settings.CheckTimerStop,
+	}
+	for name, enabled := range toggles {
+		if boolFlag(args, name) {
+			*enabled = true
+		}
+	}
+
+	// The maximum buffer size has no default to turn on.
+	if boolFlag(args, "buffer_size") && settings.CheckBufferAmount == 0 && !hasFlag(args, "bufferMax") {
+		fail("-buffer_size needs a maximum buffer size, set it with -bufferMax")
+	}
+}
+
+// Prints the documentation of a rule, found by ID or name.
+func explainRule(idOrName string) {
+	rule, ok := channelcheck.LookupRule(idOrName)
+	if !ok {
+		var ids []string
+		for _, rule := range channelcheck.Rules {
+			ids = append(ids, rule.ID)
+		}
+		fail(fmt.Sprintf("unknown rule %q, expected one of %s", idOrName, strings.Join(ids, ", ")))
+	}
+	fmt.Printf("%s %s (%s)\n\n%s.\n\n%s\n", rule.ID, rule.Name, rule.Severity, rule.Summary, rule.Explanation)
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(2)
 }
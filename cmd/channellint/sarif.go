@@ -0,0 +1,254 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	channelcheck "github.com/asymmetric-research/channel_linter"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+/*
+SARIF 2.1.0 output for code scanning tools. Only the parts of the format that the findings fill in
+are modeled: rule metadata, locations with a snippet of the reported source, the related locations,
+like the make(chan ...) of the channel, and the suggested fixes.
+*/
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	Results    []sarifResult `json:"results"`
+	ColumnKind string        `json:"columnKind"` // What the columns count, see regionOf
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	HelpURI              string             `json:"helpUri"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	RelatedLocations    []sarifLocation   `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Fixes               []sarifFix        `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndLine     int           `json:"endLine"`
+	EndColumn   int           `json:"endColumn"`
+	Snippet     *sarifMessage `json:"snippet,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion  `json:"deletedRegion"`
+	InsertedContent sarifMessage `json:"insertedContent"`
+}
+
+// Writes the findings as a SARIF log, with the metadata of every rule.
+func writeSARIF(w io.Writer, findings []finding) error {
+	driver := sarifDriver{
+		Name:           "channelcheck",
+		InformationURI: "https://github.com/asymmetric-research/channel_linter",
+	}
+	ruleIndex := make(map[string]int)
+	for i, rule := range channelcheck.Rules {
+		ruleIndex[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			Name:                 rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Summary},
+			FullDescription:      sarifMessage{Text: rule.Explanation},
+			HelpURI:              "https://github.com/asymmetric-research/channel_linter#readme",
+			DefaultConfiguration: sarifConfiguration{Level: rule.Severity},
+		})
+	}
+
+	sources := make(map[string][]byte)
+	results := []sarifResult{}
+	for _, f := range findings {
+		region := regionOf(f.fset, f.diag.Pos, f.diag.End, sources)
+		if snippet := sourceText(f.fset, f.diag.Pos, f.diag.End, sources); snippet != "" {
+			region.Snippet = &sarifMessage{Text: snippet}
+		}
+
+		level := channelcheck.SeverityWarning
+		if rule, ok := channelcheck.LookupRule(f.Rule); ok {
+			level = rule.Severity
+		}
+
+		hash := sha256.Sum256([]byte(strings.Join([]string{f.Rule, f.Package, f.Function, f.Node}, "\x00")))
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     level,
+			Message:   sarifMessage{Text: f.diag.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifactLocation(f.posn.Filename),
+				Region:           region,
+			}}},
+			RelatedLocations:    sarifRelated(f.fset, f.diag.Related, sources),
+			PartialFingerprints: map[string]string{"channelcheck/v1": hex.EncodeToString(hash[:])},
+			Fixes:               sarifFixes(f.fset, f.diag.SuggestedFixes, sources),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results, ColumnKind: "utf16CodeUnits"}},
+	})
+}
+
+func sarifRelated(fset *token.FileSet, related []analysis.RelatedInformation, sources map[string][]byte) []sarifLocation {
+	var result []sarifLocation
+	for _, info := range related {
+		region := regionOf(fset, info.Pos, info.End, sources)
+		if snippet := sourceText(fset, info.Pos, info.End, sources); snippet != "" {
+			region.Snippet = &sarifMessage{Text: snippet}
+		}
+		result = append(result, sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifactLocation(fset.Position(info.Pos).Filename),
+				Region:           region,
+			},
+			Message: &sarifMessage{Text: info.Message},
+		})
+	}
+	return result
+}
+
+func sarifFixes(fset *token.FileSet, fixes []analysis.SuggestedFix, sources map[string][]byte) []sarifFix {
+	var result []sarifFix
+	for _, fix := range fixes {
+		var changes []sarifArtifactChange
+		for _, edit := range fix.TextEdits {
+			location := artifactLocation(fset.Position(edit.Pos).Filename)
+			replacement := sarifReplacement{
+				DeletedRegion:   regionOf(fset, edit.Pos, edit.End, sources),
+				InsertedContent: sarifMessage{Text: string(edit.NewText)},
+			}
+			if n := len(changes); n > 0 && changes[n-1].ArtifactLocation == location {
+				changes[n-1].Replacements = append(changes[n-1].Replacements, replacement)
+			} else {
+				changes = append(changes, sarifArtifactChange{ArtifactLocation: location, Replacements: []sarifReplacement{replacement}})
+			}
+		}
+		result = append(result, sarifFix{Description: sarifMessage{Text: fix.Message}, ArtifactChanges: changes})
+	}
+	return result
+}
+
+/*
+Gets the region between the positions. The end column is exclusive, like in go/token. go/token counts
+columns in bytes, but SARIF counts them in UTF-16 code units, so they're converted.
+*/
+func regionOf(fset *token.FileSet, pos, end token.Pos, sources map[string][]byte) sarifRegion {
+	if !end.IsValid() {
+		end = pos
+	}
+	start, stop := fset.Position(pos), fset.Position(end)
+	return sarifRegion{
+		StartLine:   start.Line,
+		StartColumn: utf16Column(fset, pos, sources),
+		EndLine:     stop.Line,
+		EndColumn:   utf16Column(fset, end, sources),
+	}
+}
+
+// Gets the column of the position in UTF-16 code units, counting from the start of its line in the source.
+func utf16Column(fset *token.FileSet, pos token.Pos, sources map[string][]byte) int {
+	tokenFile := fset.File(pos)
+	if tokenFile == nil {
+		return 0
+	}
+	src := fileSource(tokenFile.Name(), sources)
+	lineStart, offset := tokenFile.Offset(tokenFile.LineStart(tokenFile.Line(pos))), tokenFile.Offset(pos)
+	if offset > len(src) {
+		return fset.Position(pos).Column // The file changed since it was loaded
+	}
+
+	column := 1
+	for _, r := range string(src[lineStart:offset]) {
+		column += utf16.RuneLen(r)
+	}
+	return column
+}
+
+// Uses paths relative to the working directory, so results line up with the checkout they're uploaded from.
+func artifactLocation(filename string) sarifArtifactLocation {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: "%SRCROOT%"}
+		}
+	}
+	return sarifArtifactLocation{URI: "file://" + filepath.ToSlash(filename)}
+}
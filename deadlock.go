@@ -0,0 +1,208 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+/*
+Same goroutine deadlock detection.
+
+A send on an unbuffered channel only completes once another goroutine receives from it. If the
+function that created the channel sends on it before starting any goroutine that could receive, the
+send never completes:
+
+	c := make(chan int)
+	c <- 1 // deadlock
+	<-c
+
+Unlike the blocking send check, this isn't a heuristic, so the rule has the error severity. The channel is
+tracked until it escapes the function: it's captured by a closure, passed to a 'go' statement or a
+call, or stored somewhere else. After that, someone else may receive from it.
+
+Only variables declared in the function are tracked. Package level variables can be read by any
+goroutine, and a variable that was captured by a closure or had its address taken earlier can be
+read by whoever got it, so neither is tracked even when it's assigned a new channel.
+*/
+
+// Reports sends on unbuffered channels that can never be received from.
+func (l *linter) runSelfDeadlock(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckSelfDeadlocks {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil), (*ast.FuncLit)(nil)}, func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil {
+				findSelfDeadlocks(pass, n.Body)
+			}
+		case *ast.FuncLit:
+			findSelfDeadlocks(pass, n.Body)
+		}
+	})
+	return nil, nil
+}
+
+// Walks a single function body in source order. Function literals inside it are handled on their own.
+func findSelfDeadlocks(pass *analysis.Pass, body *ast.BlockStmt) {
+	tracked := make(map[types.Object]token.Pos) // Unbuffered channels that haven't escaped yet
+	escaped := make(map[types.Object]bool)      // Variables others may read, whatever they're assigned later
+
+	// Stops tracking every channel referenced within the node. Variables captured by a function literal escape for good.
+	untrackUsed := func(node ast.Node) {
+		ast.Inspect(node, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.Ident:
+				delete(tracked, pass.TypesInfo.Uses[n])
+			case *ast.FuncLit:
+				ast.Inspect(n, func(node ast.Node) bool {
+					if ident, ok := node.(*ast.Ident); ok {
+						delete(tracked, pass.TypesInfo.Uses[ident])
+						escaped[pass.TypesInfo.Uses[ident]] = true
+					}
+					return true
+				})
+				return false
+			}
+			return true
+		})
+	}
+
+	// Starts tracking the variable if it's assigned a new unbuffered channel.
+	assign := func(lhs *ast.Ident, rhs ast.Expr) {
+		obj := pass.TypesInfo.ObjectOf(lhs)
+		if obj == nil {
+			return
+		}
+		delete(tracked, obj) // Reassigned
+		if escaped[obj] || obj.Parent() == pass.Pkg.Scope() || obj.Pos() < body.Pos() || obj.Pos() >= body.End() {
+			return // Declared outside the function, or readable by others
+		}
+
+		call, ok := ast.Unparen(rhs).(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		if isChannel, buffer := checkChannelCreation(pass, call); isChannel && !buffer.dynamic && buffer.size == 0 {
+			tracked[obj] = call.Pos()
+		}
+	}
+
+	// Checks if the expression is a tracked channel.
+	trackedChan := func(expr ast.Expr) bool {
+		ident, ok := ast.Unparen(expr).(*ast.Ident)
+		if !ok {
+			return false
+		}
+		_, ok = tracked[pass.TypesInfo.Uses[ident]]
+		return ok
+	}
+
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit, *ast.GoStmt: // A goroutine or a closure may receive from the channel, now or after it's reassigned.
+			untrackUsed(n)
+			return false
+
+		case *ast.ForStmt, *ast.RangeStmt: // A goroutine started later in the loop receives on the next iteration.
+			ast.Inspect(n, func(node ast.Node) bool {
+				switch node.(type) {
+				case *ast.FuncLit, *ast.GoStmt:
+					untrackUsed(node)
+					return false
+				}
+				return true
+			})
+			return true
+
+		case *ast.AssignStmt:
+			for _, rhs := range n.Rhs {
+				ast.Inspect(rhs, visit)
+			}
+			for i, lhs := range n.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					ast.Inspect(lhs, visit)
+					continue
+				}
+				if len(n.Lhs) == len(n.Rhs) {
+					assign(ident, n.Rhs[i])
+				} else if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
+					delete(tracked, obj)
+				}
+			}
+			return false
+
+		case *ast.ValueSpec:
+			for _, value := range n.Values {
+				ast.Inspect(value, visit)
+			}
+			if len(n.Names) == len(n.Values) {
+				for i, name := range n.Names {
+					assign(name, n.Values[i])
+				}
+			}
+			return false
+
+		case *ast.SelectStmt: // Sends in a select may pick another case, so they're not guaranteed to block.
+			for _, clause := range n.Body.List {
+				clause := clause.(*ast.CommClause)
+				if send, ok := clause.Comm.(*ast.SendStmt); ok {
+					if !trackedChan(send.Chan) {
+						ast.Inspect(send.Chan, visit)
+					}
+					ast.Inspect(send.Value, visit)
+				} else if clause.Comm != nil {
+					ast.Inspect(clause.Comm, visit)
+				}
+				for _, stmt := range clause.Body {
+					ast.Inspect(stmt, visit)
+				}
+			}
+			return false
+
+		case *ast.SendStmt:
+			ast.Inspect(n.Value, visit)
+			if !trackedChan(n.Chan) {
+				ast.Inspect(n.Chan, visit)
+				return false
+			}
+			report(pass, ruleSelfDeadlock, n, "deadlock: send on unbuffered channel before any goroutine can receive from it %q", render(pass.Fset, n))
+			return false
+
+		case *ast.UnaryExpr: // Receiving from the channel doesn't let it escape.
+			if n.Op == token.ARROW && trackedChan(n.X) {
+				return false
+			}
+			if ident, ok := ast.Unparen(n.X).(*ast.Ident); ok && n.Op == token.AND {
+				escaped[pass.TypesInfo.Uses[ident]] = true // Whoever gets the pointer sees later assignments
+			}
+
+		case *ast.CallExpr: // Neither do the builtins that inspect it.
+			if fun, ok := ast.Unparen(n.Fun).(*ast.Ident); ok && len(n.Args) == 1 && trackedChan(n.Args[0]) {
+				if builtin, ok := pass.TypesInfo.Uses[fun].(*types.Builtin); ok {
+					switch builtin.Name() {
+					case "close", "len", "cap":
+						return false
+					}
+				}
+			}
+
+		case *ast.Ident: // Any other use lets the channel escape.
+			delete(tracked, pass.TypesInfo.Uses[n])
+		}
+		return true
+	}
+
+	ast.Inspect(body, visit)
+}
@@ -18,18 +18,18 @@ func sum(s []int, c chan int) {
 		}
 	}
 
-	c <- 8
-	<-c
+	c <- 8 // want "channel send without default or timer"
+	<-c    // want "channel receive without default or timer"
 	// send sum to c
 }
 
 func main() {
 	s := []int{7, 2, 8, -9, 4, 0}
 
-	c := make(chan int) // Finds this one because no buffer size
+	c := make(chan int) // Finds this one because no buffer size // want "unbuffered channel creation detected"
 	go sum(s[:len(s)/2], c)
 	go sum(s[len(s)/2:], c)
-	x, y := <-c, <-c // receive from c
+	x, y := <-c, <-c // receive from c // want "channel receive without default or timer" "channel receive without default or timer"
 
 	fmt.Println(x, y, x+y)
 
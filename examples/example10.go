@@ -0,0 +1,81 @@
+package main
+
+import "sync"
+
+type pipeline struct {
+	done chan struct{}
+	once sync.Once
+}
+
+type broadcaster struct {
+	ch chan struct{}
+}
+
+func main10(items []int) {
+	// Invalid: closed on every iteration
+	c := make(chan int) // want "unbuffered channel creation detected"
+	for range items {
+		close(c) // want "error: channel closed in a loop may be closed more than once"
+	}
+
+	// Invalid: deferred and closed in the body
+	c2 := make(chan int) // want "unbuffered channel creation detected"
+	defer close(c2)
+	if len(items) > 0 {
+		close(c2) // want "error: channel may be closed more than once, also closed on line 23"
+	}
+
+	// Invalid: closed by every goroutine
+	c3 := make(chan int) // want "unbuffered channel creation detected"
+	for range items {
+		go func() {
+			close(c3) // want "error: channel closed in a loop may be closed more than once"
+		}()
+	}
+
+	// Valid: only one branch closes
+	c4 := make(chan int) // want "unbuffered channel creation detected"
+	if len(items) > 0 {
+		close(c4)
+	} else {
+		close(c4)
+	}
+
+	// Valid: a new channel on every iteration
+	for range items {
+		c5 := make(chan int) // want "unbuffered channel creation detected"
+		close(c5)
+	}
+
+	// Valid: the flag guards the close
+	c6 := make(chan int) // want "unbuffered channel creation detected"
+	closed := false
+	for range items {
+		if !closed {
+			close(c6)
+			closed = true
+		}
+	}
+
+	// Valid: the goroutines close through a sync.Once
+	p := &pipeline{done: make(chan struct{})} // want "unbuffered channel creation detected"
+	for range items {
+		go func() {
+			p.once.Do(func() { close(p.done) })
+		}()
+	}
+}
+
+func (p *pipeline) stop() {
+	// Invalid: the field is closed twice
+	close(p.done)
+	close(p.done) // want "error: channel may be closed more than once, also closed on line 71"
+}
+
+func (b *broadcaster) broadcast(items []int) {
+	// Valid: a new channel is stored after every close
+	for range items {
+		close(b.ch)
+		b.ch = make(chan struct{}) // want "unbuffered channel creation detected"
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "fmt"
+
+type notifier struct {
+	ch chan struct{}
+}
+
+func main11(items []int) {
+	results := make(chan int, len(items)) // want "dynamic channel buffer size"
+
+	// Invalid: the channel is closed before the last send
+	for _, item := range items {
+		results <- item // want "channel send without default or timer"
+	}
+	close(results)
+	results <- 0 // want "channel send without default or timer" "error: send on channel that may already be closed, closed on line 16"
+
+	// Invalid: the goroutine is started after the close
+	done := make(chan struct{}, 1)
+	close(done)
+	go func() {
+		done <- struct{}{} // want "channel send without default or timer" "error: send on channel that may already be closed, closed on line 21"
+	}()
+
+	// Valid: closed once every send is done
+	out := make(chan int, len(items)) // want "dynamic channel buffer size"
+	for _, item := range items {
+		out <- item // want "channel send without default or timer"
+	}
+	close(out)
+	for v := range out { // want "range over channel blocks until the channel is closed"
+		fmt.Println(v)
+	}
+
+	// Valid: the captured channel is replaced after the close
+	ready := make(chan int, 1)
+	go func() {
+		fmt.Println(<-ready) // want "channel receive without default or timer"
+	}()
+	close(ready)
+	ready = make(chan int, 1)
+	ready <- 1 // want "channel send without default or timer"
+}
+
+func (n *notifier) notify() {
+	// Valid: a new channel is stored before the send
+	close(n.ch)
+	n.ch = make(chan struct{}, 1)
+	n.ch <- struct{}{} // want "channel send without default or timer"
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+func process(item int) error {
+	if item < 0 {
+		return errors.New("negative")
+	}
+	return nil
+}
+
+// Invalid: returns on the first error, the other senders block forever
+func firstError(items []int) error {
+	errc := make(chan error) // want "goroutine leak: goroutines started in a loop send on this channel" "unbuffered channel creation detected"
+	for _, item := range items {
+		go func() {
+			errc <- process(item) // want "channel send without default or timer"
+		}()
+	}
+	for range items {
+		if err := <-errc; err != nil { // want "channel receive without default or timer"
+			return err
+		}
+	}
+	return nil
+}
+
+// Invalid: the sender is stranded after the timeout
+func withTimeout(item int) error {
+	result := make(chan error) // want "goroutine leak: 1 goroutine" "unbuffered channel creation detected"
+	go func() {
+		result <- process(item) // want "channel send without default or timer"
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(time.Second):
+		return errors.New("timeout")
+	}
+}
+
+// Valid: every result is received
+func allErrors(items []int) []error {
+	errc := make(chan error) // want "unbuffered channel creation detected"
+	for _, item := range items {
+		go func() {
+			errc <- process(item) // want "channel send without default or timer"
+		}()
+	}
+	var errs []error
+	for range items {
+		errs = append(errs, <-errc) // want "channel receive without default or timer"
+	}
+	return errs
+}
+
+// Valid: the buffer holds the result after the timeout
+func bufferedTimeout(item int) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- process(item) // want "channel send without default or timer"
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(time.Second):
+		return errors.New("timeout")
+	}
+}
+
+// Valid: the senders give up once the context is cancelled
+func cancellable(ctx context.Context, items []int) error {
+	errc := make(chan error) // want "unbuffered channel creation detected"
+	for _, item := range items {
+		go func() {
+			select {
+			case errc <- process(item):
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return <-errc // want "channel receive without default or timer"
+}
+
+// Valid: the loop starts 3 senders, so with the one after it there are 4, and 4 results are received
+func countedLoop() int {
+	results := make(chan int) // want "unbuffered channel creation detected"
+	for i := 0; i < 3; i++ {
+		go func() {
+			results <- i // want "channel send without default or timer"
+		}()
+	}
+	go func() {
+		results <- 3 // want "channel send without default or timer"
+	}()
+	return <-results + <-results + <-results + <-results // want "channel receive without default or timer" "channel receive without default or timer" "channel receive without default or timer" "channel receive without default or timer"
+}
+
+// Invalid: the loop starts 3 senders, but only 2 results are received
+func countedLoopShort() int {
+	results := make(chan int) // want "goroutine leak: 3 goroutine" "unbuffered channel creation detected"
+	for i := 0; i <= 2; i++ {
+		go func() {
+			results <- 1 // want "channel send without default or timer"
+		}()
+	}
+	return <-results + <-results // want "channel receive without default or timer" "channel receive without default or timer"
+}
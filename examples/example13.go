@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+type result struct {
+	value int
+}
+
+// Fixed with 'return ctx.Err()'
+func produce(ctx context.Context, ch chan<- int) error {
+	ch <- 1 // want "channel send without default or timer"
+	return nil
+}
+
+// Fixed with zero values for the other results
+func produceResult(ctx context.Context, ch chan<- result) (result, time.Duration, error) {
+	ch <- result{value: 1} // want "channel send without default or timer"
+	return result{}, 0, nil
+}
+
+// Fixed with a bare return in the goroutine
+func produceAsync(ctx context.Context, ch chan<- int) {
+	go func() {
+		for i := 0; i < 3; i++ {
+			ch <- i // want "channel send without default or timer"
+		}
+	}()
+}
+
+// No fix: there's no context in scope
+func produceNoContext(ch chan<- int) {
+	ch <- 1 // want "channel send without default or timer"
+}
+
+func main13() {
+	ch := make(chan int, 1)
+	produce(context.Background(), ch)
+	produceAsync(context.Background(), ch)
+	produceNoContext(ch)
+	produceResult(context.Background(), make(chan result, 1))
+}
@@ -0,0 +1,15 @@
+package main
+
+// The fix sizes the buffer for the three goroutines sending on the channel
+func fetchAll() []int {
+	results := make(chan int)    // want "unbuffered channel creation detected"
+	go func() { results <- 1 }() // want "channel send without default or timer"
+	go func() { results <- 2 }() // want "channel send without default or timer"
+	go func() { results <- 3 }() // want "channel send without default or timer"
+
+	return []int{<-results, <-results, <-results} // want "channel receive without default or timer" "channel receive without default or timer" "channel receive without default or timer"
+}
+
+func main14() {
+	fetchAll()
+}
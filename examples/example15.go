@@ -0,0 +1,43 @@
+package main
+
+// Suppressed on the same line
+func suppressLine(ch chan int) {
+	ch <- 1 //nolint:channelcheck
+}
+
+// Suppressed by the directive on the preceding line
+func suppressPrecedingLine(ch chan int) {
+	//channelcheck:ignore blocking_send the receiver never stops
+	ch <- 1
+	ch <- 2 // Invalid: only the next line is covered // want "channel send without default or timer"
+}
+
+// Suppressed for the whole function
+//
+//nolint:blocking_send // Callers always receive
+func suppressFunction(ch chan int) {
+	ch <- 1
+	ch <- 2
+}
+
+// Suppressed by rule ID and by rule name
+func suppressByID(ch chan int) {
+	ch <- 1 //channelcheck:ignore CC001 buffered by the caller
+	ch <- 2 //nolint:blocking-send
+}
+
+// Invalid: the directive has no reason, and names another rule
+func suppressInvalid(ch chan int) {
+	//channelcheck:ignore blocking_send
+	ch <- 1 // want "channel send without default or timer"
+	ch <- 2 //nolint:double_close // want "channel send without default or timer"
+}
+
+func main15() {
+	ch := make(chan int, 1)
+	suppressLine(ch)
+	suppressPrecedingLine(ch)
+	suppressFunction(ch)
+	suppressByID(ch)
+	suppressInvalid(ch)
+}
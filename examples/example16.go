@@ -0,0 +1,37 @@
+package main
+
+// The blocking sends here carry related information pointing to where the channel came from.
+
+type eventBus struct {
+	events chan string
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{events: make(chan string, 4)}
+}
+
+func (p *eventBus) publish(event string) {
+	p.events <- event // Points to the make in newEventBus // want "channel send without default or timer"
+}
+
+func forward(out chan<- string, event string) {
+	out <- event // Points to both calls in main16 and the make // want "channel send without default or timer"
+}
+
+func main16() {
+	results := make(chan string) // want "unbuffered channel creation detected"
+	go forward(results, "a")
+	go forward(results, "b")
+	<-results // want "channel receive without default or timer"
+	<-results // want "channel receive without default or timer"
+
+	p := newEventBus()
+	p.publish("start")
+
+	var done chan bool
+	done = make(chan bool) // want "unbuffered channel creation detected"
+	go func() {
+		done <- true // Points to the make through the captured variable // want "channel send without default or timer"
+	}()
+	<-done // want "channel receive without default or timer"
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+type registry struct {
+	mu      sync.Mutex
+	entries map[string]int
+	updates chan string
+}
+
+func (r *registry) set(key string, value int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[key] = value
+	r.updates <- key // Finds this one, the deferred unlock runs after the send // want "channel send while r.mu is locked, locked on line 15" "channel send without default or timer"
+}
+
+func (r *registry) setUnlocked(key string, value int) {
+	r.mu.Lock()
+	r.entries[key] = value
+	r.mu.Unlock()
+	r.updates <- key // Ignores this one, the lock was released // want "channel send without default or timer"
+}
+
+func (r *registry) trySet(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	select { // Ignores this one because of default
+	case r.updates <- key:
+	default:
+	}
+}
+
+type cache struct {
+	sync.RWMutex
+	values map[string]string
+}
+
+func (c *cache) wait(ready chan struct{}, timeout <-chan time.Time) {
+	c.RLock()
+	select { // Finds this one, there's no default // want "select without default while c is locked, locked on line 43"
+	case <-ready:
+	case <-timeout:
+	}
+	c.RUnlock()
+	<-ready // Ignores this one, the lock was released // want "channel receive without default or timer"
+}
+
+func main17() {
+	var mu sync.Mutex
+	results := make(chan int, 1)
+	mu.Lock()
+	if len(results) == 0 {
+		mu.Unlock()
+		return
+	}
+	v := <-results // Finds this one, only one branch unlocks // want "channel receive while mu is locked, locked on line 55" "channel receive without default or timer"
+	mu.Unlock()
+	_ = v
+}
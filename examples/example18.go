@@ -0,0 +1,36 @@
+package main
+
+func drain(done <-chan struct{}, values <-chan int) {
+	for {
+		select {
+		case <-done: // want "channel receive without default or timer"
+			break // Finds this one, it only leaves the select // want "break inside select only leaves the select, not the enclosing loop"
+		case v := <-values: // want "channel receive without default or timer"
+			if v < 0 {
+				break // Finds this one too // want "break inside select only leaves the select, not the enclosing loop"
+			}
+			for range v {
+				break // Ignores this one, it leaves the inner loop
+			}
+		}
+	}
+}
+
+func drainLabeled(done <-chan struct{}, values <-chan int) {
+outer:
+	for range values { // want "range over channel blocks until the channel is closed"
+		select {
+		case <-done:
+			break // Finds this one, the fix reuses the label // want "break inside select only leaves the select, not the enclosing loop"
+		default:
+			break outer // Ignores this one, it's labeled
+		}
+	}
+}
+
+func main18() {
+	done := make(chan struct{}, 1)
+	values := make(chan int, 1)
+	go drain(done, values)
+	drainLabeled(done, values)
+}
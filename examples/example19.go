@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+func spinSend(ch chan int, v int) {
+	for {
+		select { // Finds this one, the default does nothing // want "select with a default spins in a loop without a condition"
+		case ch <- v: // Finds this one too, the default doesn't protect it // want "channel send without default or timer"
+			return
+		default:
+		}
+	}
+}
+
+func spinFlag(ready *atomic.Bool, ch chan int) {
+	for {
+		select { // Finds this one, checking an atomic doesn't wait // want "select with a default spins in a loop without a condition"
+		case v := <-ch: // want "channel receive without default or timer"
+			_ = v
+		default:
+			if ready.Load() {
+				continue
+			}
+		}
+	}
+}
+
+func pollSleep(ch chan int) {
+	for {
+		select { // Ignores this one, the default sleeps
+		case v := <-ch:
+			_ = v
+		default:
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+func pollUntilEmpty(ch chan int) {
+	for {
+		select { // Ignores this one, the default leaves the function
+		case v := <-ch:
+			_ = v
+		default:
+			return
+		}
+	}
+}
+
+func pollBounded(ch chan int) {
+	for i := 0; i < 10; i++ {
+		select { // Ignores this one, the loop has a condition
+		case ch <- i:
+		default:
+		}
+	}
+}
+
+func pollCount(ch chan int) {
+	n := 0
+	for {
+		select { // Ignores this one, the loop body breaks out of the loop
+		case v := <-ch:
+			_ = v
+		default:
+		}
+		n++
+		if n > 100 {
+			break
+		}
+	}
+}
+
+func spinSwitch(ch chan int, mode int) {
+	for {
+		select { // Finds this one, the break only leaves the switch // want "select with a default spins in a loop without a condition"
+		case v := <-ch: // want "channel receive without default or timer"
+			_ = v
+		default:
+		}
+		switch mode {
+		case 0:
+			break
+		}
+	}
+}
+
+func main19() {
+	ch := make(chan int, 1)
+	spinSend(ch, 1)
+	var ready atomic.Bool
+	go spinFlag(&ready, ch)
+	pollSleep(ch)
+	pollUntilEmpty(ch)
+	pollBounded(ch)
+	pollCount(ch)
+	go spinSwitch(ch, 0)
+}
@@ -5,8 +5,8 @@ import (
 )
 
 func main2() {
-	ch := make(chan int)
-	ch2 := make(chan int)
+	ch := make(chan int)  // want "unbuffered channel creation detected"
+	ch2 := make(chan int) // want "unbuffered channel creation detected"
 	var chInterface interface{} = ch2
 
 	select {
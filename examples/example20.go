@@ -0,0 +1,48 @@
+package main
+
+import "time"
+
+func waitEach(values <-chan int) {
+	for {
+		select {
+		case v := <-values:
+			_ = v
+		case <-time.After(time.Second): // Finds this one, a new timer every iteration // want "time.After in a loop allocates a new timer on every iteration"
+			return
+		}
+	}
+}
+
+func waitAll(values <-chan int) {
+	timeout := time.After(time.Minute) // Ignores this one, it covers the whole loop
+	for {
+		select {
+		case v := <-values:
+			_ = v
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func heartbeat(done <-chan struct{}) {
+	tick := time.Tick(time.Second) // Finds this one before Go 1.23, the ticker outlives the function
+	for {
+		select {
+		case <-tick:
+		case <-done:
+			return
+		}
+	}
+}
+
+var refresh = time.Tick(time.Hour) // Ignores this one, it lives as long as the program
+
+func main20() {
+	values := make(chan int, 1)
+	done := make(chan struct{}, 1)
+	go heartbeat(done)
+	waitEach(values)
+	waitAll(values)
+	<-refresh
+}
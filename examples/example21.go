@@ -0,0 +1,65 @@
+package main
+
+import "time"
+
+func pollUntilDone(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second) // Finds this one, returning on done leaves it running // want "time.NewTicker used in a select case is not stopped on some return path"
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+func pollStopped(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second) // Ignores this one, the stop is deferred
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+func waitOnce(result <-chan int) int {
+	timer := time.NewTimer(time.Second) // Finds this one, only the timeout path stops it // want "time.NewTimer used in a select case is not stopped on some return path"
+	select {
+	case v := <-result:
+		return v
+	case <-timer.C:
+		timer.Stop()
+		return 0
+	}
+}
+
+func waitClosure(result <-chan int) int {
+	timer := time.NewTimer(time.Second) // Ignores this one, the deferred function stops it
+	defer func() {
+		timer.Stop()
+	}()
+	select {
+	case v := <-result:
+		return v
+	case <-timer.C:
+		return 0
+	}
+}
+
+func newPoller() *time.Ticker {
+	ticker := time.NewTicker(time.Second) // Ignores this one, the caller stops it
+	return ticker
+}
+
+func main21() {
+	done := make(chan struct{}, 1)
+	result := make(chan int, 1)
+	go pollUntilDone(done)
+	go pollStopped(done)
+	waitOnce(result)
+	waitClosure(result)
+	newPoller().Stop()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/asymmetric-research/channel_linter/examples/helpers"
+)
+
+func sendBackground(ch chan int) {
+	ctx := context.Background()
+	select { // Finds the send, ctx.Done() is nil and never fires
+	case ch <- 1: // want "channel send in a select whose ctx.Done"
+	case <-ctx.Done(): // want "channel receive that can only end through a ctx.Done"
+	}
+}
+
+func sendTODO(ch chan int) {
+	ctx := context.WithValue(context.TODO(), "key", "value")
+	select { // Finds the send, the value doesn't make the context cancellable
+	case ch <- 1: // want "channel send in a select whose ctx.Done"
+	case <-ctx.Done(): // want "channel receive that can only end through a ctx.Done"
+	}
+}
+
+func sendCancellable(ch chan int) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	select { // Ignores the send, the timeout cancels the context
+	case ch <- 1:
+	case <-ctx.Done():
+	}
+}
+
+func sendNoFix(ch chan int) error {
+	ctx := context.Background()
+	ch <- 1 // Finds this one, but doesn't suggest a select on ctx.Done() // want "channel send without default or timer"
+	return ctx.Err()
+}
+
+func never() <-chan struct{} {
+	ctx := context.Background()
+	return ctx.Done()
+}
+
+func sendNeverHelper(ch chan int) {
+	select { // Finds the send, the helper returns the Done channel of context.Background
+	case ch <- 1: // want "channel send in a select whose ctx.Done"
+	case <-never(): // want "channel receive that can only end through a ctx.Done"
+	}
+
+	select { // Finds the send, the same helper in another package
+	case ch <- 2: // want "channel send in a select whose ctx.Done"
+	case <-helpers.Never(): // want "channel receive that can only end through a ctx.Done"
+	}
+}
+
+func main22() {
+	ch := make(chan int, 4)
+	sendBackground(ch)
+	sendTODO(ch)
+	sendCancellable(ch)
+	_ = sendNoFix(ch)
+	sendNeverHelper(ch)
+}
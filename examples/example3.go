@@ -7,7 +7,7 @@ import (
 func main3() {
 	ch := make(chan int, 2)
 	ch2 := make(chan int, 2)
-	ch3 := make(chan int)
+	ch3 := make(chan int) // want "unbuffered channel creation detected"
 	chFunc := func() chan int { return ch3 }
 	var chInterface interface{} = ch2
 	channels := []chan int{ch, ch2}
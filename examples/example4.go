@@ -14,7 +14,7 @@ func main4() {
 	// Valid
 	case chInterface.(chan int) <- 100: // Type assertion
 		fmt.Println("Sent to ch2 via interface")
-	// Valid <---- need to catch this
+	// Valid: time.After always fires
 	case <-(time.After(500 * time.Millisecond)):
 		fmt.Println("Timeout")
 	}
@@ -5,11 +5,15 @@ import (
 	"time"
 )
 
-// Doesn't detect this currently
+// Only known at runtime. Reported with the dynamic buffer size check.
 var channelAmount = 100
 
+// Constants are evaluated and checked against the buffer limit.
+const queueSize = 1024
+
 func main5() {
-	ch := make(chan int, channelAmount)
+	ch := make(chan int, channelAmount) // want "dynamic channel buffer size"
+	_ = make(chan int, 2*queueSize)     // want "channel buffer size exceeds the specified limit"
 	var chInterface interface{} = ch
 
 	select {
@@ -17,12 +21,12 @@ func main5() {
 	// Valid
 	case chInterface.(chan int) <- 3: // Type assertion
 		fmt.Println("Sent to ch2 via interface")
-	// Valid <---- need to catch this
+	// Valid: time.After always fires
 	case <-time.After(500 * time.Millisecond):
 		fmt.Println("Timeout")
 	}
 
-	// These are all cases it doesn't catch :(
+	// Valid: the timers are traced back to the 'time' package
 	d := 500 * time.Millisecond
 	var timer interface{} = time.NewTimer(d).C
 	select {
@@ -54,13 +58,31 @@ func main5() {
 		// ...
 	}
 
-	timer3 := time.NewTimer(500)
+	timer3 := time.NewTimer(500) // want "time.NewTimer used in a select case is not stopped on some return path"
 	select {
 	case ch <- 4:
 		// ...
 	case <-timer3.C:
 		// ...
 	}
+
+	// Valid: carries time.Time, so it's accepted even though it's not traced to a timer
+	notTimer := make(chan time.Time) // want "unbuffered channel creation detected"
+	select {
+	case ch <- 5:
+		// ...
+	case <-notTimer:
+		// ...
+	}
+
+	// Invalid: the 'C' field of an AfterFunc timer is nil
+	afterFunc := time.AfterFunc(d, func() {})
+	select {
+	case ch <- 6: // want "channel send without default or timer"
+		// ...
+	case <-afterFunc.C: // want "channel receive without default or timer"
+		// ...
+	}
 }
 
 func getTimer(d time.Duration) <-chan time.Time {
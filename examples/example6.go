@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type worker struct {
+	ctx context.Context
+	out chan int
+}
+
+func (w *worker) context() context.Context {
+	return w.ctx
+}
+
+func main6() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan int) // want "unbuffered channel creation detected"
+
+	// Valid: cancellation through a context variable
+	select {
+	case ch <- 1:
+	case <-ctx.Done():
+	}
+
+	// Valid: derived context
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second)
+	defer cancelTimeout()
+	select {
+	case ch <- 2:
+	case _, ok := <-timeoutCtx.Done():
+		fmt.Println(ok)
+	}
+
+	w := &worker{ctx: ctx, out: ch}
+
+	// Valid: context stored in a struct field
+	select {
+	case w.out <- 3:
+	case <-w.ctx.Done():
+	}
+
+	// Valid: context returned from a method call
+	select {
+	case w.out <- 4:
+	case <-w.context().Done():
+	}
+
+	// Invalid: receiving from another channel is not a cancellation path
+	done := make(chan struct{}) // want "unbuffered channel creation detected"
+	select {
+	case ch <- 5: // want "channel send without default or timer"
+	case <-done: // want "channel receive without default or timer"
+	}
+}
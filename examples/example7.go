@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/asymmetric-research/channel_linter/examples/helpers"
+)
+
+func main7() {
+	ch := make(chan int) // want "unbuffered channel creation detected"
+	d := 500 * time.Millisecond
+
+	// Valid: the helper returns time.After, known from the facts of the helpers package
+	select {
+	case ch <- 1:
+	case <-helpers.Timeout(d):
+	}
+
+	// Valid: Done() returns ctx.Done() of the service context, which is cancelled on return
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc := helpers.NewService(ctx)
+	select {
+	case ch <- 2:
+	case <-svc.Done():
+	}
+
+	// Valid: the channel is stored in a variable first
+	done := svc.Done()
+	select {
+	case ch <- 3:
+	case <-done:
+	}
+}
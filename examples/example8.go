@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func main8(ctx context.Context) {
+	ch := make(chan int, 1)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// Invalid: blocks forever if nothing is sent
+	v := <-ch // want "channel receive without default or timer"
+	fmt.Println(v)
+
+	// Invalid: blocks until the channel is closed
+	for v := range ch { // want "range over channel blocks until the channel is closed"
+		fmt.Println(v)
+	}
+
+	// Valid: protected by a cancellation case
+	select {
+	case v := <-ch:
+		fmt.Println(v)
+	case <-ctx.Done():
+	}
+
+	// Valid: waiting for cancellation or a timer always finishes
+	<-ctx.Done()
+	<-time.After(time.Second)
+	<-ticker.C
+	for range ticker.C {
+		break
+	}
+
+	// Invalid: no fallback case
+	select {
+	case v := <-ch: // want "channel receive without default or timer"
+		fmt.Println(v)
+	case <-make(chan struct{}): // want "channel receive without default or timer" "unbuffered channel creation detected"
+	}
+}
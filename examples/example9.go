@@ -0,0 +1,60 @@
+package main
+
+import "fmt"
+
+var events chan int
+
+func consume() {
+	for v := range events { // want "range over channel blocks until the channel is closed"
+		fmt.Println(v)
+	}
+}
+
+func main9() {
+	// Invalid: nothing can receive, guaranteed deadlock
+	c := make(chan int) // want "unbuffered channel creation detected"
+	c <- 1              // want "channel send without default or timer" "error: deadlock: send on unbuffered channel before any goroutine can receive from it"
+	fmt.Println(<-c)    // want "channel receive without default or timer"
+
+	// Valid: a goroutine receives first
+	c2 := make(chan int) // want "unbuffered channel creation detected"
+	go func() {
+		fmt.Println(<-c2) // want "channel receive without default or timer"
+	}()
+	c2 <- 1 // want "channel send without default or timer"
+
+	// Valid: buffered
+	c3 := make(chan int, 1)
+	c3 <- 1           // want "channel send without default or timer"
+	fmt.Println(<-c3) // want "channel receive without default or timer"
+
+	// Valid: the channel escapes to another function that may receive
+	c4 := make(chan int) // want "unbuffered channel creation detected"
+	startReceiver(c4)
+	c4 <- 1 // want "channel send without default or timer"
+
+	// Valid: the receiver is started on the first iteration
+	c5 := make(chan int) // want "unbuffered channel creation detected"
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			c5 <- i // want "channel send without default or timer"
+		}
+		go func() { <-c5 }() // want "channel receive without default or timer"
+	}
+
+	// Valid: a package level channel, the goroutine reads the variable
+	events = make(chan int) // want "unbuffered channel creation detected"
+	go consume()
+	events <- 1 // want "channel send without default or timer"
+
+	// Valid: the closure reads the variable after it's assigned
+	var c6 chan int
+	receive := func() { <-c6 } // want "channel receive without default or timer"
+	c6 = make(chan int)        // want "unbuffered channel creation detected"
+	go receive()
+	c6 <- 1 // want "channel send without default or timer"
+}
+
+func startReceiver(c chan int) {
+	go func() { <-c }() // want "channel receive without default or timer"
+}
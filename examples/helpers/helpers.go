@@ -0,0 +1,31 @@
+// Package helpers holds channel helpers that are used from another package in the examples.
+package helpers
+
+import (
+	"context"
+	"time"
+)
+
+// Timeout returns a channel that fires after d.
+func Timeout(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Service owns a context and exposes its cancellation channel.
+type Service struct {
+	ctx context.Context
+}
+
+func NewService(ctx context.Context) *Service {
+	return &Service{ctx: ctx}
+}
+
+// Done is closed when the service is stopped.
+func (s *Service) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Never returns the Done channel of a context that is never cancelled.
+func Never() <-chan struct{} {
+	return context.Background().Done()
+}
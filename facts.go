@@ -0,0 +1,381 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+/*
+Facts about functions that return channels. These are exported for every function in a package and
+imported when a later package calls the function, so helpers like
+
+	func wrapAfter(d time.Duration) <-chan time.Time { return time.After(d) }
+
+can be used as a select timeout case in any package without keeping an allowlist.
+
+An analyzer with facts runs on every dependency, the standard library included, so the facts are
+computed from the syntax and types only. The SSA form is only built for the packages being analyzed,
+by originsAnalyzer, which looks calls up in the origins computed here instead of tracing the called
+functions again. So a helper gets the same origin in its own package as in the ones importing it.
+*/
+
+// ReturnsTimeoutChannel is attached to functions whose result is always a timer channel,
+// such as time.After or the 'C' field of a time.Timer.
+type ReturnsTimeoutChannel struct{}
+
+func (*ReturnsTimeoutChannel) AFact() {}
+
+func (*ReturnsTimeoutChannel) String() string { return "returnsTimeoutChannel" }
+
+// ReturnsCancellationChannel is attached to functions whose result is always the Done channel
+// of a context.Context.
+type ReturnsCancellationChannel struct{}
+
+func (*ReturnsCancellationChannel) AFact() {}
+
+func (*ReturnsCancellationChannel) String() string { return "returnsCancellationChannel" }
+
+// ReturnsNeverCancelledChannel is attached to functions whose result is always the Done channel
+// of a context from context.Background or context.TODO, which is nil.
+type ReturnsNeverCancelledChannel struct{}
+
+func (*ReturnsNeverCancelledChannel) AFact() {}
+
+func (*ReturnsNeverCancelledChannel) String() string { return "returnsNeverCancelledChannel" }
+
+// ReturnsNilChannel is attached to functions whose result never delivers, such as a nil channel
+// or the 'C' field of a timer from time.AfterFunc.
+type ReturnsNilChannel struct{}
+
+func (*ReturnsNilChannel) AFact() {}
+
+func (*ReturnsNilChannel) String() string { return "returnsNilChannel" }
+
+// Gets a new fact for the origin. Unknown origins have none.
+func originFact(origin chanOrigin) analysis.Fact {
+	switch origin {
+	case originTimer:
+		return new(ReturnsTimeoutChannel)
+	case originCancel:
+		return new(ReturnsCancellationChannel)
+	case originNeverCancel:
+		return new(ReturnsNeverCancelledChannel)
+	case originOther:
+		return new(ReturnsNilChannel)
+	}
+	return nil
+}
+
+/*
+Computes the origin of the channel returned by every function of the package and exports the facts.
+The result also has the origin of every function from another package that it uses, since facts can
+only be read while this analyzer runs. originsAnalyzer looks calls up in it, so a function has the
+same origin whether it's called from its own package or another one.
+*/
+var factsAnalyzer = &analysis.Analyzer{
+	Name:             "channelfacts",
+	Doc:              "computes the origin of channels returned by functions",
+	Run:              runFacts,
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf(funcOrigins(nil)),
+	FactTypes:        []analysis.Fact{new(ReturnsTimeoutChannel), new(ReturnsCancellationChannel), new(ReturnsNeverCancelledChannel), new(ReturnsNilChannel)},
+}
+
+// Origin of the channel returned by functions. Functions that aren't in it have an unknown origin.
+type funcOrigins map[*types.Func]chanOrigin
+
+/*
+Traces every channel received from in the package, so the rules can tell if a receive is a timeout.
+Only runs on the packages being analyzed, since it has no facts of its own.
+*/
+var originsAnalyzer = &analysis.Analyzer{
+	Name:             "channelorigins",
+	Doc:              "computes the origin of channels received from",
+	Run:              runOrigins,
+	RunDespiteErrors: true,
+	Requires:         []*analysis.Analyzer{ssaAnalyzer, factsAnalyzer},
+	ResultType:       reflect.TypeOf(recvOrigins(nil)),
+}
+
+// Origin of the channel of every receive, keyed by the position of the '<-'.
+type recvOrigins map[token.Pos]chanOrigin
+
+func runFacts(pass *analysis.Pass) (interface{}, error) {
+	origins := make(funcOrigins)
+	if !hasTypeInfo(pass) {
+		return origins, nil
+	}
+
+	for _, obj := range pass.TypesInfo.Uses {
+		fn, ok := obj.(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg() == pass.Pkg || !returnsChannel(fn.Signature()) {
+			continue
+		}
+		fn = fn.Origin() // Instantiation of a generic function
+		for _, origin := range []chanOrigin{originTimer, originCancel, originNeverCancel, originOther} {
+			if pass.ImportObjectFact(fn, originFact(origin)) {
+				origins[fn] = origin
+				break
+			}
+		}
+	}
+
+	for fn, origin := range localFuncOrigins(pass, origins) {
+		origins[fn] = origin
+		if fact := originFact(origin); fact != nil {
+			pass.ExportObjectFact(fn, fact)
+		}
+	}
+	return origins, nil
+}
+
+func runOrigins(pass *analysis.Pass) (interface{}, error) {
+	ssaInfo := pass.ResultOf[ssaAnalyzer].(*buildssa.SSA)
+	origins := make(recvOrigins)
+	if ssaInfo == nil {
+		return origins, nil // No type information. Receives fall back to the syntax.
+	}
+
+	facts := &channelFacts{origins: pass.ResultOf[factsAnalyzer].(funcOrigins)}
+	for pos, ch := range recvChannels(ssaInfo) {
+		origins[pos] = facts.traceChannel(ch, make(map[ssa.Value]bool))
+	}
+	return origins, nil
+}
+
+// channelFacts resolves the origin of channels returned by function calls.
+type channelFacts struct {
+	origins funcOrigins
+}
+
+// Gets the origin of the channel returned by a call to the function.
+func (f *channelFacts) callOrigin(fn *ssa.Function) chanOrigin {
+	if fn == nil {
+		return originUnknown // Dynamic call, such as an interface method or a function value
+	}
+	obj, _ := fn.Object().(*types.Func)
+	return calleeOrigin(obj, f.origins)
+}
+
+/*
+Computes the origin of the channel returned by every function declared in the package from its
+syntax. Functions in the same package can call each other, so this is repeated until nothing
+changes. Calls to other packages are looked up in 'imported'.
+*/
+func localFuncOrigins(pass *analysis.Pass, imported funcOrigins) funcOrigins {
+	decls := make(map[*types.Func]*ast.FuncDecl)
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok && returnsChannel(obj.Signature()) {
+				decls[obj] = fn
+			}
+		}
+	}
+
+	f := &syntaxFacts{pass: pass, origins: make(funcOrigins)}
+	for fn, origin := range imported {
+		f.origins[fn] = origin
+	}
+	local := make(funcOrigins)
+	for changed, rounds := true, 0; changed && rounds <= len(decls); rounds++ {
+		changed = false
+		for obj, decl := range decls {
+			f.body = decl.Body
+			var origins []chanOrigin
+			ast.Inspect(decl.Body, func(node ast.Node) bool {
+				switch n := node.(type) {
+				case *ast.FuncLit:
+					return false
+				case *ast.ReturnStmt:
+					if len(n.Results) == 1 {
+						origins = append(origins, f.exprOrigin(n.Results[0], make(map[types.Object]bool)))
+					} else {
+						origins = append(origins, originUnknown) // Named result
+					}
+				}
+				return true
+			})
+			origin := mergeOrigins(origins)
+			if origin != local[obj] {
+				local[obj] = origin
+				f.origins[obj] = origin
+				changed = true
+			}
+		}
+	}
+	return local
+}
+
+/*
+syntaxFacts resolves the origin of returned channels from the syntax. This is the counterpart of
+traceChannel for the syntax, and classifies calls, contexts and timer fields with the same helpers.
+*/
+type syntaxFacts struct {
+	pass    *analysis.Pass
+	origins funcOrigins    // Functions resolved so far, in this package or imported
+	body    *ast.BlockStmt // Body of the function being resolved
+}
+
+// Follows the expression back to where the channel came from, through the local variables of the function.
+func (f *syntaxFacts) exprOrigin(expr ast.Expr, seen map[types.Object]bool) chanOrigin {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.CallExpr:
+		if tv, ok := f.pass.TypesInfo.Types[e.Fun]; ok && tv.IsType() && len(e.Args) == 1 {
+			return f.exprOrigin(e.Args[0], seen) // Conversion, like to <-chan time.Time
+		}
+		if isContextDone(f.pass, e) {
+			if f.neverCancelled(ast.Unparen(e.Fun).(*ast.SelectorExpr).X, make(map[types.Object]bool)) {
+				return originNeverCancel
+			}
+			return originCancel
+		}
+		fn, _ := typeutil.Callee(f.pass.TypesInfo, e).(*types.Func)
+		return calleeOrigin(fn, f.origins)
+
+	case *ast.SelectorExpr: // timer.C
+		var constructor *types.Func
+		if call, ok := ast.Unparen(e.X).(*ast.CallExpr); ok {
+			constructor, _ = typeutil.Callee(f.pass.TypesInfo, call).(*types.Func)
+		}
+		return timerFieldOrigin(f.pass.TypesInfo.TypeOf(e.X), e.Sel.Name, constructor)
+
+	case *ast.TypeAssertExpr:
+		return f.exprOrigin(e.X, seen)
+
+	case *ast.Ident:
+		if e.Name == "nil" && f.pass.TypesInfo.Uses[e] == types.Universe.Lookup("nil") {
+			return originOther // A nil channel blocks forever
+		}
+		obj := f.localVar(e)
+		if obj == nil {
+			return originUnknown
+		}
+		if seen[obj] {
+			return originTimer // Already visited, the other assignments decide.
+		}
+		seen[obj] = true
+
+		values, ok := f.assignedValues(obj)
+		if !ok {
+			return originUnknown
+		}
+		origins := make([]chanOrigin, 0, len(values))
+		for _, value := range values {
+			if value == nil {
+				origins = append(origins, originOther) // The zero value is a nil channel
+			} else {
+				origins = append(origins, f.exprOrigin(value, seen))
+			}
+		}
+		return mergeOrigins(origins)
+	}
+	return originUnknown
+}
+
+// Same as neverCancelled, following the context through the local variables of the function.
+func (f *syntaxFacts) neverCancelled(expr ast.Expr, seen map[types.Object]bool) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.CallExpr:
+		fn, _ := typeutil.Callee(f.pass.TypesInfo, e).(*types.Func)
+		never, fromParent := contextNeverCancelled(fn)
+		if fromParent && len(e.Args) > 0 {
+			return f.neverCancelled(e.Args[0], seen)
+		}
+		return never
+
+	case *ast.Ident:
+		obj := f.localVar(e)
+		if obj == nil {
+			return false
+		}
+		if seen[obj] {
+			return true // Already visited, the other assignments decide.
+		}
+		seen[obj] = true
+
+		values, ok := f.assignedValues(obj)
+		if !ok || len(values) == 0 {
+			return false
+		}
+		for _, value := range values {
+			if value == nil || !f.neverCancelled(value, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Gets the variable declared in the function being resolved. Returns nil for parameters and package level variables.
+func (f *syntaxFacts) localVar(id *ast.Ident) *types.Var {
+	obj, ok := f.pass.TypesInfo.Uses[id].(*types.Var)
+	if !ok || obj.Parent() == f.pass.Pkg.Scope() || obj.Pos() < f.body.Pos() || obj.Pos() >= f.body.End() {
+		return nil
+	}
+	return obj
+}
+
+/*
+Gets every value assigned to the local variable, with nil for its zero value. Returns false if one
+of them can't be followed, like a value from a call with several results or one stored through a
+pointer.
+*/
+func (f *syntaxFacts) assignedValues(obj *types.Var) ([]ast.Expr, bool) {
+	var values []ast.Expr
+	known := true
+	ast.Inspect(f.body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && f.pass.TypesInfo.ObjectOf(id) == obj {
+					if len(n.Lhs) != len(n.Rhs) {
+						known = false
+					} else {
+						values = append(values, n.Rhs[i])
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if f.pass.TypesInfo.Defs[name] != obj {
+					continue
+				}
+				switch {
+				case len(n.Values) == 0:
+					values = append(values, nil)
+				case len(n.Values) != len(n.Names):
+					known = false
+				default:
+					values = append(values, n.Values[i])
+				}
+			}
+		case *ast.UnaryExpr:
+			if id, ok := n.X.(*ast.Ident); ok && n.Op == token.AND && f.pass.TypesInfo.Uses[id] == obj {
+				known = false // Could be assigned through the pointer
+			}
+		}
+		return true
+	})
+	return values, known
+}
+
+// Checks if the function has a single result that can be received from.
+func returnsChannel(sig *types.Signature) bool {
+	if sig.Results().Len() != 1 {
+		return false
+	}
+	ch, ok := sig.Results().At(0).Type().Underlying().(*types.Chan)
+	return ok && ch.Dir() != types.SendOnly
+}
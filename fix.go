@@ -0,0 +1,290 @@
+package channelcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+/*
+Suggested fix for blocking sends. If a context is in scope, the send is wrapped in a select that
+gives up once the context is cancelled:
+
+	select {
+	case ch <- v:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+The return matches the signature of the enclosing function: zero values for every result, with
+ctx.Err() in place of a trailing error. No fix is offered if a result type can't be written in the
+file, such as a type from a package the file doesn't import.
+*/
+func cancelSendFix(pass *analysis.Pass, send *ast.SendStmt, stack []ast.Node) []analysis.SuggestedFix {
+	if !hasTypeInfo(pass) || len(stack) < 2 {
+		return nil
+	}
+	// The send must be a statement of its own to become a select. Not e.g. the post statement of a
+	// for loop, or a case of a select without a fallback.
+	switch parent := stack[len(stack)-2].(type) {
+	case *ast.BlockStmt, *ast.CaseClause, *ast.LabeledStmt:
+	case *ast.CommClause:
+		if parent.Comm == send {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	file, ok := stack[0].(*ast.File)
+	if !ok {
+		return nil
+	}
+	sig := enclosingSignature(pass, stack)
+	if sig == nil {
+		return nil
+	}
+	ctx := contextInScope(pass, send.Pos())
+	if ctx == nil {
+		return nil
+	}
+	ret, ok := cancelReturn(pass, file, sig, ctx.Name())
+	if !ok {
+		return nil
+	}
+
+	indent := strings.Repeat("\t", pass.Fset.Position(send.Pos()).Column-1)
+	var text strings.Builder
+	fmt.Fprintf(&text, "select {\n")
+	fmt.Fprintf(&text, "%scase %s:\n", indent, render(pass.Fset, send))
+	fmt.Fprintf(&text, "%scase <-%s.Done():\n", indent, ctx.Name())
+	fmt.Fprintf(&text, "%s\t%s\n", indent, ret)
+	fmt.Fprintf(&text, "%s}", indent)
+
+	return []analysis.SuggestedFix{{
+		Message: fmt.Sprintf("Wrap the send in a select on %s.Done()", ctx.Name()),
+		TextEdits: []analysis.TextEdit{{
+			Pos:     send.Pos(),
+			End:     send.End(),
+			NewText: []byte(text.String()),
+		}},
+	}}
+}
+
+// Gets the signature of the innermost function containing the last node of the stack.
+func enclosingSignature(pass *analysis.Pass, stack []ast.Node) *types.Signature {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncLit:
+			sig, _ := pass.TypesInfo.TypeOf(fn).(*types.Signature)
+			return sig
+		case *ast.FuncDecl:
+			obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+			if !ok {
+				return nil
+			}
+			return obj.Type().(*types.Signature)
+		}
+	}
+	return nil
+}
+
+/*
+Finds a local variable or parameter holding a context that's visible at the position. The innermost
+one wins, and a variable named 'ctx' is preferred within the same scope. Contexts that are never
+cancelled are skipped, since a select on their Done would still block.
+*/
+func contextInScope(pass *analysis.Pass, pos token.Pos) *types.Var {
+	for scope := pass.Pkg.Scope().Innermost(pos); scope != nil && scope != pass.Pkg.Scope(); scope = scope.Parent() {
+		var found *types.Var
+		for _, name := range scope.Names() {
+			v, ok := scope.Lookup(name).(*types.Var)
+			if !ok || v.Pos() >= pos || !isContextType(v.Type()) || isBackgroundVar(pass, v) {
+				continue
+			}
+			if found == nil || name == "ctx" {
+				found = v
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+/*
+Checks if the variable is only ever assigned context.Background() or context.TODO(), in its
+declaration. Any other assignment, like 'ctx, cancel = context.WithCancel(ctx)', may make it
+cancellable.
+*/
+func isBackgroundVar(pass *analysis.Pass, v *types.Var) bool {
+	var file *ast.File
+	for _, f := range pass.Files {
+		if f.FileStart <= v.Pos() && v.Pos() < f.FileEnd {
+			file = f
+		}
+	}
+	if file == nil {
+		return false
+	}
+
+	background, reassigned := false, false
+	ast.Inspect(file, func(node ast.Node) bool {
+		var lhs []ast.Expr
+		var rhs []ast.Expr
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			lhs, rhs = n.Lhs, n.Rhs
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				lhs = append(lhs, name)
+			}
+			rhs = n.Values
+		default:
+			return true
+		}
+		for i, expr := range lhs {
+			id, ok := expr.(*ast.Ident)
+			if !ok || pass.TypesInfo.ObjectOf(id) != v {
+				continue
+			}
+			if id.Pos() == v.Pos() && len(rhs) == len(lhs) && isBackgroundCall(pass, rhs[i]) {
+				background = true
+			} else {
+				reassigned = true
+			}
+		}
+		return true
+	})
+	return background && !reassigned
+}
+
+// Checks if the expression is a call to context.Background or context.TODO.
+func isBackgroundCall(pass *analysis.Pass, expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == "context" && (fn.Name() == "Background" || fn.Name() == "TODO")
+}
+
+// Builds the return statement taken when the context is cancelled.
+func cancelReturn(pass *analysis.Pass, file *ast.File, sig *types.Signature, ctx string) (string, bool) {
+	results := sig.Results()
+	if results.Len() == 0 {
+		return "return", true
+	}
+
+	values := make([]string, results.Len())
+	for i := 0; i < results.Len(); i++ {
+		t := results.At(i).Type()
+		if i == results.Len()-1 && types.Identical(t, types.Universe.Lookup("error").Type()) {
+			values[i] = ctx + ".Err()"
+			continue
+		}
+		zero, ok := zeroValue(pass, file, t)
+		if !ok {
+			return "", false
+		}
+		values[i] = zero
+	}
+	return "return " + strings.Join(values, ", "), true
+}
+
+// Writes the zero value of the type as it would be spelled in the file.
+func zeroValue(pass *analysis.Pass, file *ast.File, t types.Type) (string, bool) {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+		return "", false
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+		return "nil", true
+	case *types.Interface:
+		if _, ok := t.(*types.TypeParam); !ok {
+			return "nil", true
+		}
+	}
+
+	name, ok := typeString(pass, file, t)
+	if !ok {
+		return "", false
+	}
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + name + ")", true
+	}
+	return name + "{}", true // Structs and arrays
+}
+
+// Writes the type with the package names imported by the file.
+func typeString(pass *analysis.Pass, file *ast.File, t types.Type) (string, bool) {
+	ok := true
+	name := types.TypeString(t, func(pkg *types.Package) string {
+		if pkg == pass.Pkg {
+			return ""
+		}
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || path != pkg.Path() {
+				continue
+			}
+			if spec.Name == nil {
+				return pkg.Name()
+			}
+			if spec.Name.Name != "_" && spec.Name.Name != "." {
+				return spec.Name.Name
+			}
+		}
+		ok = false
+		return pkg.Name()
+	})
+	return name, ok
+}
+
+/*
+Suggested fix for unbuffered channels. The buffer is sized for the goroutines sending on the channel
+when they can be counted, so none of them has to wait for a receiver. Otherwise the configured
+default is used, if any.
+*/
+func bufferSizeFix(call *ast.CallExpr, senders int, defaultSize uint64) []analysis.SuggestedFix {
+	var message string
+	var size uint64
+	switch {
+	case senders > 0:
+		size = uint64(senders)
+		message = fmt.Sprintf("Add a buffer of %d, one per sending goroutine", size)
+	case defaultSize > 0:
+		size = defaultSize
+		message = fmt.Sprintf("Add a buffer of %d", size)
+	default:
+		return nil
+	}
+
+	return []analysis.SuggestedFix{{
+		Message: message,
+		TextEdits: []analysis.TextEdit{{
+			Pos:     call.Args[0].End(),
+			End:     call.Args[0].End(),
+			NewText: []byte(fmt.Sprintf(", %d", size)),
+		}},
+	}}
+}
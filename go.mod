@@ -2,10 +2,12 @@ module github.com/asymmetric-research/channel_linter
 
 go 1.24.0
 
-require golang.org/x/tools v0.30.0
+require (
+	github.com/golangci/plugin-module-register v0.1.1
+	golang.org/x/tools v0.30.0
+)
 
 require (
-	github.com/golangci/plugin-module-register v0.1.1 // indirect
 	golang.org/x/mod v0.23.0 // indirect
 	golang.org/x/sync v0.11.0 // indirect
 )
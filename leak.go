@@ -0,0 +1,509 @@
+package channelcheck
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"math"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+/*
+Goroutine leak detection for the abandoned result channel pattern.
+
+	errc := make(chan error)
+	for _, item := range items {
+		go func() { errc <- process(item) }()
+	}
+	for range items {
+		if err := <-errc; err != nil {
+			return err // The remaining senders block forever
+		}
+	}
+
+For every channel created in a function, the goroutines that send on it are counted and compared
+with the buffer size plus the fewest receives done on any path to a return. If there are more
+senders than that, some of them are stranded. Goroutines started in a loop like
+'for i := 0; i < 3; i++' count once per iteration, and in any other loop as an unknown number.
+
+Only the simple shape is handled: the channel never leaves the function except to be captured by
+goroutines that send on it. Anything else, like passing it to another function, may add receivers
+we can't see, so the channel is skipped.
+*/
+
+// Number of senders when the goroutines are started in a loop with an unknown number of iterations.
+const unboundedSenders = math.MaxInt
+
+// Reports channels whose sending goroutines can be stranded.
+func (l *linter) runGoroutineLeak(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckGoroutineLeaks {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil
+	}
+	ssaInfo := pass.ResultOf[ssaAnalyzer].(*buildssa.SSA)
+	makeCalls := builtinCalls(pass, "make")
+
+	for _, fn := range ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				makeChan, ok := instr.(*ssa.MakeChan)
+				if !ok {
+					continue
+				}
+				call, ok := makeCalls[makeChan.Pos()]
+				if !ok {
+					continue
+				}
+				isChannel, buffer := checkChannelCreation(pass, call)
+				if !isChannel || buffer.dynamic {
+					continue // A buffer sized at runtime, like len(items), is the usual fix.
+				}
+
+				holders := make(map[ssa.Value]bool)
+				uses, ok := chanUses(makeChan, holders)
+				if !ok {
+					continue
+				}
+				senders, ok := countSenders(uses, holders)
+				if !ok || senders == 0 {
+					continue
+				}
+				receives, ok := minReceives(fn, uses)
+				if !ok {
+					continue
+				}
+
+				if senders == unboundedSenders {
+					report(pass, ruleGoroutineLeak, call, "goroutine leak: goroutines started in a loop send on this channel, but it has a buffer of %d and only %d receives on some return path %q", buffer.size, receives, render(pass.Fset, call))
+				} else if uint64(senders) > buffer.size+uint64(receives) {
+					report(pass, ruleGoroutineLeak, call, "goroutine leak: %d goroutine(s) send on this channel, but it has a buffer of %d and only %d receives on some return path %q", senders, buffer.size, receives, render(pass.Fset, call))
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+/*
+Counts the goroutines sending on every channel created in the package, keyed by the position of the
+'(' of the make call. Only channels with a known number of senders are included, which is the buffer
+size that lets all of them finish without a receiver.
+*/
+func goroutineSenders(ssaInfo *buildssa.SSA) map[token.Pos]int {
+	counts := make(map[token.Pos]int)
+	for _, fn := range ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				makeChan, ok := instr.(*ssa.MakeChan)
+				if !ok {
+					continue
+				}
+				holders := make(map[ssa.Value]bool)
+				uses, ok := chanUses(makeChan, holders)
+				if !ok {
+					continue
+				}
+				if senders, ok := countSenders(uses, holders); ok && senders > 0 && senders != unboundedSenders {
+					counts[makeChan.Pos()] = senders
+				}
+			}
+		}
+	}
+	return counts
+}
+
+/*
+Gets the instructions that use the channel. Closures capture variables by reference, so a captured
+channel is stored in a variable first and every load of that variable counts as the channel. The
+channel value and the variables holding it are added to 'holders'.
+
+Returns false if the channel is stored anywhere else or the variable is assigned another channel.
+*/
+func chanUses(value ssa.Value, holders map[ssa.Value]bool) ([]ssa.Instruction, bool) {
+	holders[value] = true
+
+	var uses []ssa.Instruction
+	for _, ref := range *value.Referrers() {
+		switch ref := ref.(type) {
+		case *ssa.Store:
+			if holders[ref.Val] && ref.Val != value {
+				continue // The store that put the channel in this variable
+			}
+			alloc, ok := ref.Addr.(*ssa.Alloc)
+			if !ok || ref.Val != value {
+				return nil, false
+			}
+			more, ok := chanUses(alloc, holders)
+			if !ok {
+				return nil, false
+			}
+			uses = append(uses, more...)
+
+		case *ssa.UnOp:
+			if ref.Op != token.MUL {
+				uses = append(uses, ref)
+				continue
+			}
+			more, ok := chanUses(ref, holders) // Load of the variable
+			if !ok {
+				return nil, false
+			}
+			uses = append(uses, more...)
+
+		default:
+			uses = append(uses, ref)
+		}
+	}
+	return uses, true
+}
+
+/*
+Counts the goroutines that send on the channel without a select. Sends in a select are assumed to
+have another way out. Returns false if the channel is used in any other way.
+*/
+func countSenders(uses []ssa.Instruction, holders map[ssa.Value]bool) (int, bool) {
+	senders := 0
+	for _, use := range uses {
+		switch use := use.(type) {
+		case *ssa.UnOp, *ssa.Select: // Receives in the function itself
+			continue
+
+		case *ssa.Call: // close, len and cap don't receive
+			if _, ok := use.Call.Value.(*ssa.Builtin); ok {
+				continue
+			}
+			return 0, false
+
+		case *ssa.MakeClosure:
+			goInstr, sends, ok := goroutineSends(use, holders)
+			if !ok {
+				return 0, false
+			}
+			if !sends {
+				continue
+			}
+			started := 1
+			if reaches(goInstr, goInstr) {
+				if started, ok = tripCount(goInstr.Block()); !ok {
+					senders = unboundedSenders
+				}
+			}
+			if senders != unboundedSenders {
+				senders += started
+			}
+
+		default:
+			return 0, false
+		}
+	}
+	return senders, true
+}
+
+/*
+Gets the number of iterations of the loop containing the block, for loops like
+'for i := 0; i < 3; i++' with constant bounds that don't change the counter in the body. Returns
+false for any other loop, and for loops nested in another one.
+*/
+func tripCount(block *ssa.BasicBlock) (int, bool) {
+	header := loopHeader(block)
+	if header == nil {
+		return 0, false
+	}
+	cond, ok := header.Instrs[len(header.Instrs)-1].(*ssa.If)
+	if !ok || !header.Succs[0].Dominates(block) {
+		return 0, false // Not only run while the condition holds
+	}
+	compare, ok := cond.Cond.(*ssa.BinOp)
+	if !ok || compare.Op != token.LSS && compare.Op != token.LEQ {
+		return 0, false
+	}
+	end, ok := constInt(compare.Y)
+	if !ok {
+		return 0, false
+	}
+
+	// Captured counters are copied into a new variable on every iteration, so the phi is over the variables.
+	counter, captured := compare.X, false
+	if load, ok := counter.(*ssa.UnOp); ok && load.Op == token.MUL {
+		counter, captured = load.X, true
+	}
+	phi, ok := counter.(*ssa.Phi)
+	if !ok || phi.Block() != header {
+		return 0, false
+	}
+
+	start, starts := 0, 0
+	for i, edge := range phi.Edges {
+		pred := header.Preds[i]
+		switch {
+		case header.Dominates(pred): // Back edge
+			if !loopStep(edge, phi, captured) {
+				return 0, false
+			}
+		case loopHeader(pred) != nil:
+			return 0, false // Nested loop, the outer one starts it again
+		default:
+			if start, ok = loopStart(edge, captured); !ok {
+				return 0, false
+			}
+			starts++
+		}
+	}
+	if starts != 1 {
+		return 0, false
+	}
+	if captured && storedIn(phi) {
+		return 0, false // The body assigns the counter
+	}
+
+	count := end - start
+	if compare.Op == token.LEQ {
+		count++
+	}
+	return max(count, 0), true
+}
+
+// Gets the initial value of a loop counter, stored in a variable if it's captured.
+func loopStart(value ssa.Value, captured bool) (int, bool) {
+	if !captured {
+		return constInt(value)
+	}
+	alloc, ok := value.(*ssa.Alloc)
+	if !ok {
+		return 0, false
+	}
+	var stores []*ssa.Store
+	for _, ref := range *alloc.Referrers() {
+		if store, ok := ref.(*ssa.Store); ok && store.Addr == alloc {
+			stores = append(stores, store)
+		}
+	}
+	if len(stores) != 1 {
+		return 0, false
+	}
+	return constInt(stores[0].Val)
+}
+
+/*
+Checks that the value on the back edge is the counter plus one. A captured counter is copied into the
+variable of the next iteration, which is then incremented.
+*/
+func loopStep(value ssa.Value, phi *ssa.Phi, captured bool) bool {
+	counter := ssa.Value(phi)
+	if captured {
+		alloc, ok := value.(*ssa.Alloc)
+		if !ok {
+			return false
+		}
+		var last *ssa.Store
+		for _, ref := range *alloc.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && store.Addr == alloc {
+				last = store
+			}
+		}
+		if last == nil {
+			return false
+		}
+		value, counter = last.Val, alloc
+	}
+
+	increment, ok := value.(*ssa.BinOp)
+	if !ok || increment.Op != token.ADD {
+		return false
+	}
+	if step, ok := constInt(increment.Y); !ok || step != 1 {
+		return false
+	}
+	if !captured {
+		return increment.X == counter
+	}
+	load, ok := increment.X.(*ssa.UnOp)
+	return ok && load.Op == token.MUL && load.X == counter
+}
+
+// Checks if anything is stored in the variables of the phi, like an assignment to the counter in the body.
+func storedIn(phi *ssa.Phi) bool {
+	for _, ref := range *phi.Referrers() {
+		if store, ok := ref.(*ssa.Store); ok && store.Addr == phi {
+			return true
+		}
+	}
+	return false
+}
+
+// Gets the value of an integer constant.
+func constInt(value ssa.Value) (int, bool) {
+	c, ok := value.(*ssa.Const)
+	if !ok || c.Value == nil || c.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	n, exact := constant.Int64Val(c.Value)
+	return int(n), exact
+}
+
+/*
+Checks that the closure is only started as a goroutine and only sends on the channel. Returns the go
+statement and whether it does a plain send.
+*/
+func goroutineSends(closure *ssa.MakeClosure, holders map[ssa.Value]bool) (*ssa.Go, bool, bool) {
+	refs := *closure.Referrers()
+	if len(refs) != 1 {
+		return nil, false, false
+	}
+	goInstr, ok := refs[0].(*ssa.Go)
+	if !ok || goInstr.Call.Value != closure {
+		return nil, false, false
+	}
+
+	sends := false
+	fn := closure.Fn.(*ssa.Function)
+	for i, binding := range closure.Bindings {
+		if !holders[binding] {
+			continue
+		}
+		freeVar := fn.FreeVars[i]
+		freeVarHolders := make(map[ssa.Value]bool)
+		uses, ok := chanUses(freeVar, freeVarHolders)
+		if !ok {
+			return nil, false, false
+		}
+		for _, use := range uses {
+			switch use := use.(type) {
+			case *ssa.Send:
+				sends = true
+			case *ssa.Select: // Sends in a select aren't counted, but receives make it a receiver.
+				for _, state := range use.States {
+					if state.Dir != types.SendOnly && freeVarHolders[state.Chan] {
+						return nil, false, false
+					}
+				}
+			default:
+				return nil, false, false
+			}
+		}
+	}
+	return goInstr, sends, true
+}
+
+/*
+Computes the fewest receives from the channel on any path from the entry of the function to a
+return. Receives in a select may pick another case, so they don't count.
+
+Returns false if a receive happens in a loop that can only be left through the loop condition, such
+as 'for range items { <-errc }' or ranging over the channel. The number of iterations isn't known,
+so it's assumed to drain the channel.
+*/
+func minReceives(fn *ssa.Function, uses []ssa.Instruction) (int, bool) {
+	received := make(map[*ssa.BasicBlock]int)
+	for _, use := range uses {
+		switch ref := use.(type) {
+		case *ssa.UnOp:
+			if ref.Op != token.ARROW {
+				continue
+			}
+			if ref.CommaOk || drainsInLoop(ref) {
+				return 0, false
+			}
+			received[ref.Block()]++
+		case *ssa.Select:
+			if drainsInLoop(ref) {
+				return 0, false
+			}
+		}
+	}
+
+	// Shortest path where the cost of a block is the number of receives in it.
+	const unvisited = math.MaxInt
+	cost := make(map[*ssa.BasicBlock]int)
+	for _, block := range fn.Blocks {
+		cost[block] = unvisited
+	}
+	done := make(map[*ssa.BasicBlock]bool)
+	cost[fn.Blocks[0]] = received[fn.Blocks[0]]
+
+	fewest := unvisited
+	for {
+		var next *ssa.BasicBlock
+		for _, block := range fn.Blocks {
+			if !done[block] && cost[block] != unvisited && (next == nil || cost[block] < cost[next]) {
+				next = block
+			}
+		}
+		if next == nil {
+			break
+		}
+		done[next] = true
+
+		if _, ok := next.Instrs[len(next.Instrs)-1].(*ssa.Return); ok {
+			fewest = min(fewest, cost[next])
+		}
+		for _, succ := range next.Succs {
+			cost[succ] = min(cost[succ], cost[next]+received[succ])
+		}
+	}
+
+	if fewest == unvisited {
+		return 0, false // Never returns
+	}
+	return fewest, true
+}
+
+/*
+Checks if the receive is in a loop that can't be left right after the receive. Leaving the loop
+early, like returning on the first error or on a timeout case, is exactly what strands the senders.
+*/
+func drainsInLoop(instr ssa.Instruction) bool {
+	block := instr.Block()
+	header := loopHeader(block)
+	if header == nil {
+		return false
+	}
+
+	// Look for a return that can be reached without going through the loop header again.
+	seen := map[*ssa.BasicBlock]bool{header: true}
+	stack := append([]*ssa.BasicBlock(nil), block.Succs...)
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		if _, ok := next.Instrs[len(next.Instrs)-1].(*ssa.Return); ok {
+			return false
+		}
+		stack = append(stack, next.Succs...)
+	}
+	return true
+}
+
+/*
+Gets the header of the innermost loop containing the block, or nil if it's not in a loop. A loop
+header dominates the whole loop and is the target of the back edge.
+*/
+func loopHeader(block *ssa.BasicBlock) *ssa.BasicBlock {
+	var header *ssa.BasicBlock
+	for _, candidate := range block.Parent().Blocks {
+		if !candidate.Dominates(block) || !blockReaches(block.Succs, candidate) || !hasBackEdge(candidate) {
+			continue
+		}
+		if header == nil || header.Dominates(candidate) {
+			header = candidate
+		}
+	}
+	return header
+}
+
+func hasBackEdge(header *ssa.BasicBlock) bool {
+	for _, pred := range header.Preds {
+		if header.Dominates(pred) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package channelcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/ssa"
+)
+
+/*
+Channel operations done while a mutex is held.
+
+	mu.Lock()
+	defer mu.Unlock()
+	results <- r // Blocks with the lock held, and whoever receives may need the lock first
+
+A mutex is held from a call to Lock or RLock until a call to Unlock or RUnlock on the same mutex.
+Starting at every lock, the control flow graph is walked until the mutex is unlocked. A deferred
+unlock only runs when the function returns, so it doesn't end the walk. Every send, receive and
+select without a default found on the way blocks with the lock held.
+
+Mutexes are matched the same way as channels in close.go, so 'mu', 's.mu' and an embedded mutex
+are all tracked. Operations in goroutines and function literals don't hold the caller's lock.
+*/
+
+// Gets the mutex if the call locks or unlocks a sync.Mutex or sync.RWMutex.
+func mutexCall(call *ssa.CallCommon) (mutex ssa.Value, lock bool, ok bool) {
+	callee := call.StaticCallee()
+	if callee == nil || len(call.Args) == 0 {
+		return nil, false, false
+	}
+	fn, ok := callee.Object().(*types.Func)
+	if !ok {
+		return nil, false, false
+	}
+	switch fn.FullName() {
+	case "(*sync.Mutex).Lock", "(*sync.RWMutex).Lock", "(*sync.RWMutex).RLock":
+		return call.Args[0], true, true
+	case "(*sync.Mutex).Unlock", "(*sync.RWMutex).Unlock", "(*sync.RWMutex).RUnlock":
+		return call.Args[0], false, true
+	}
+	return nil, false, false
+}
+
+// Gets the '<-' of a send or receive, or the 'select' of a select without a default.
+func blockingOp(instr ssa.Instruction) (token.Pos, bool) {
+	switch instr := instr.(type) {
+	case *ssa.Send:
+		return instr.Pos(), true
+	case *ssa.UnOp:
+		return instr.Pos(), instr.Op == token.ARROW
+	case *ssa.Select:
+		return instr.Pos(), instr.Blocking
+	}
+	return token.NoPos, false
+}
+
+/*
+Finds the channel operations done while a mutex is held in every function of the package. Returns
+the position of every such operation, see blockingOp, mapped to the position of the '(' of the lock.
+*/
+func lockedOps(ssaInfo *buildssa.SSA) map[token.Pos]token.Pos {
+	locked := make(map[token.Pos]token.Pos)
+	for _, fn := range ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for i, instr := range block.Instrs {
+				call, ok := instr.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				mutex, lock, ok := mutexCall(call.Common())
+				if !ok || !lock {
+					continue
+				}
+				walkLocked(block, i+1, addrKey(mutex), func(pos token.Pos) {
+					if _, ok := locked[pos]; !ok {
+						locked[pos] = call.Pos()
+					}
+				})
+			}
+		}
+	}
+	return locked
+}
+
+// Walks the instructions from block.Instrs[start] until the mutex is unlocked, calling 'found' for every channel operation.
+func walkLocked(block *ssa.BasicBlock, start int, key any, found func(pos token.Pos)) {
+	seen := make(map[*ssa.BasicBlock]bool)
+	var walk func(block *ssa.BasicBlock, start int)
+	walk = func(block *ssa.BasicBlock, start int) {
+		for _, instr := range block.Instrs[start:] {
+			if call, ok := instr.(*ssa.Call); ok {
+				if mutex, lock, ok := mutexCall(call.Common()); ok && !lock && addrKey(mutex) == key {
+					return
+				}
+			}
+			if pos, ok := blockingOp(instr); ok && pos.IsValid() {
+				found(pos)
+			}
+		}
+		for _, succ := range block.Succs {
+			if !seen[succ] {
+				seen[succ] = true
+				walk(succ, 0)
+			}
+		}
+	}
+	walk(block, start)
+}
+
+// Reports sends, receives and selects without a default that block while a mutex is held.
+func (l *linter) runLockedChannelOps(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckLockedChannelOps {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil
+	}
+	locked := lockedOps(pass.ResultOf[ssaAnalyzer].(*buildssa.SSA))
+	if len(locked) == 0 {
+		return nil, nil
+	}
+
+	// The mutex is named by the receiver of the lock call, like 's.mu' in 's.mu.Lock()'.
+	mutexNames := make(map[token.Pos]string)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		call := node.(*ast.CallExpr)
+		if sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr); ok {
+			mutexNames[call.Lparen] = render(pass.Fset, sel.X)
+		}
+	})
+	held := func(pos token.Pos) (string, int, bool) {
+		lockPos, ok := locked[pos]
+		if !ok {
+			return "", 0, false
+		}
+		return mutexNames[lockPos], pass.Fset.Position(lockPos).Line, true
+	}
+
+	nodeFilter := []ast.Node{(*ast.SendStmt)(nil), (*ast.UnaryExpr)(nil), (*ast.RangeStmt)(nil), (*ast.SelectStmt)(nil)}
+	inspect.Preorder(nodeFilter, func(node ast.Node) {
+		switch n := node.(type) {
+		case *ast.SendStmt:
+			if mutex, line, ok := held(n.Arrow); ok {
+				report(pass, ruleLockedChannelOp, n, "channel send while %s is locked, locked on line %d %q", mutex, line, render(pass.Fset, n))
+			}
+		case *ast.UnaryExpr:
+			if mutex, line, ok := held(n.OpPos); ok && n.Op == token.ARROW {
+				report(pass, ruleLockedChannelOp, n, "channel receive while %s is locked, locked on line %d %q", mutex, line, render(pass.Fset, n))
+			}
+		case *ast.RangeStmt:
+			if mutex, line, ok := held(n.For); ok {
+				reportDiagnostic(pass, ruleLockedChannelOp, analysis.Diagnostic{
+					Pos:     n.For,
+					End:     n.X.End(), // Up to the channel, without the body
+					Message: fmt.Sprintf("range over channel while %s is locked, locked on line %d %q", mutex, line, render(pass.Fset, n.X)),
+				})
+			}
+		case *ast.SelectStmt:
+			if mutex, line, ok := held(n.Select); ok {
+				reportDiagnostic(pass, ruleLockedChannelOp, analysis.Diagnostic{
+					Pos:     n.Select,
+					End:     n.Body.Lbrace, // Only the select keyword, without the cases
+					Message: fmt.Sprintf("select without default while %s is locked, locked on line %d", mutex, line),
+				})
+			}
+		}
+	})
+	return nil, nil
+}
@@ -0,0 +1,215 @@
+package channelcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+/*
+Related information for sends: where the channel being sent on comes from. The channel is followed
+back through the SSA form to the make call that created it. Channels that come from a parameter
+point to the call sites that pass them in, and channels read from a struct field point to the places
+storing the field. Only the package being analyzed is searched.
+*/
+
+// Most places listed for a single send, so a helper called from everywhere doesn't flood the diagnostic.
+const maxRelated = 5
+
+type channelSources struct {
+	pass      *analysis.Pass
+	ssaInfo   *buildssa.SSA
+	makeCalls map[token.Pos]*ast.CallExpr
+}
+
+/*
+Finds where the channel of every send comes from, keyed by the position of the '<-'. Sends in a
+select are included.
+*/
+func sendSources(pass *analysis.Pass, ssaInfo *buildssa.SSA) map[token.Pos][]analysis.RelatedInformation {
+	sources := &channelSources{pass: pass, ssaInfo: ssaInfo, makeCalls: builtinCalls(pass, "make")}
+	related := make(map[token.Pos][]analysis.RelatedInformation)
+	for _, fn := range ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Send:
+					related[instr.Pos()] = sources.trace(instr.Chan, make(map[ssa.Value]bool), nil)
+				case *ssa.Select:
+					for _, state := range instr.States {
+						if state.Dir == types.SendOnly {
+							related[state.Pos] = sources.trace(state.Chan, make(map[ssa.Value]bool), nil)
+						}
+					}
+				}
+			}
+		}
+	}
+	return related
+}
+
+// Follows the channel back to where it came from, appending the places found to 'related'.
+func (s *channelSources) trace(value ssa.Value, seen map[ssa.Value]bool, related []analysis.RelatedInformation) []analysis.RelatedInformation {
+	if seen[value] || len(related) >= maxRelated {
+		return related
+	}
+	seen[value] = true
+
+	switch v := value.(type) {
+	case *ssa.MakeChan:
+		return append(related, s.creation(v))
+
+	case *ssa.ChangeType:
+		return s.trace(v.X, seen, related)
+
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			related = s.trace(edge, seen, related)
+		}
+		return related
+
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return related
+		}
+		switch addr := v.X.(type) {
+		case *ssa.Alloc, *ssa.Global: // A variable. Follow what's stored in it.
+			return s.traceStores(func(store *ssa.Store) bool { return store.Addr == addr }, "", seen, related)
+		case *ssa.FreeVar:
+			return s.traceFreeVar(addr, seen, related)
+		case *ssa.FieldAddr:
+			field := fieldOf(addr)
+			return s.traceStores(func(store *ssa.Store) bool {
+				other, ok := store.Addr.(*ssa.FieldAddr)
+				return ok && fieldOf(other) == field
+			}, field.Name(), seen, related)
+		}
+
+	case *ssa.Parameter:
+		return s.traceParam(v, seen, related)
+	}
+	return related
+}
+
+// Describes the make call that created the channel.
+func (s *channelSources) creation(makeChan *ssa.MakeChan) analysis.RelatedInformation {
+	fn := makeChan.Parent().RelString(s.pass.Pkg)
+	call, ok := s.makeCalls[makeChan.Pos()]
+	if !ok {
+		return analysis.RelatedInformation{Pos: makeChan.Pos(), Message: fmt.Sprintf("channel created in %s", fn)}
+	}
+
+	var buffer string
+	switch _, size := checkChannelCreation(s.pass, call); {
+	case size.omitted:
+		buffer = "unbuffered"
+	case size.dynamic:
+		buffer = fmt.Sprintf("with a buffer of %s", render(s.pass.Fset, call.Args[1]))
+	default:
+		buffer = fmt.Sprintf("with a buffer of %d", size.size)
+	}
+	return analysis.RelatedInformation{
+		Pos:     call.Pos(),
+		End:     call.End(),
+		Message: fmt.Sprintf("channel created here, %s, in %s", buffer, fn),
+	}
+}
+
+/*
+Follows the values stored to the addresses matched by 'matches'. For struct fields, the store itself
+is listed when the stored channel can't be followed further.
+*/
+func (s *channelSources) traceStores(matches func(store *ssa.Store) bool, field string, seen map[ssa.Value]bool, related []analysis.RelatedInformation) []analysis.RelatedInformation {
+	for _, fn := range s.ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				store, ok := instr.(*ssa.Store)
+				if !ok || !matches(store) || len(related) >= maxRelated {
+					continue
+				}
+				before := len(related)
+				related = s.trace(store.Val, seen, related)
+				if field != "" && len(related) == before && store.Pos().IsValid() {
+					related = append(related, analysis.RelatedInformation{
+						Pos:     store.Pos(),
+						Message: fmt.Sprintf("channel stored in field %s here, in %s", field, fn.RelString(s.pass.Pkg)),
+					})
+				}
+			}
+		}
+	}
+	return related
+}
+
+// Follows a variable captured by a function literal to the function that created the closure.
+func (s *channelSources) traceFreeVar(freeVar *ssa.FreeVar, seen map[ssa.Value]bool, related []analysis.RelatedInformation) []analysis.RelatedInformation {
+	fn := freeVar.Parent()
+	index := -1
+	for i, other := range fn.FreeVars {
+		if other == freeVar {
+			index = i
+		}
+	}
+	if fn.Parent() == nil || index < 0 {
+		return related
+	}
+
+	for _, block := range fn.Parent().Blocks {
+		for _, instr := range block.Instrs {
+			if closure, ok := instr.(*ssa.MakeClosure); ok && closure.Fn == fn {
+				binding := closure.Bindings[index]
+				if alloc, ok := binding.(*ssa.Alloc); ok {
+					related = s.traceStores(func(store *ssa.Store) bool { return store.Addr == alloc }, "", seen, related)
+				} else {
+					related = s.trace(binding, seen, related)
+				}
+			}
+		}
+	}
+	return related
+}
+
+// Lists the call sites in the package that pass the channel in as the parameter, and where they got it.
+func (s *channelSources) traceParam(param *ssa.Parameter, seen map[ssa.Value]bool, related []analysis.RelatedInformation) []analysis.RelatedInformation {
+	fn := param.Parent()
+	index := -1
+	for i, other := range fn.Params {
+		if other == param {
+			index = i
+		}
+	}
+	if index < 0 {
+		return related
+	}
+
+	for _, caller := range s.ssaInfo.SrcFuncs {
+		for _, block := range caller.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(ssa.CallInstruction)
+				if !ok || call.Common().StaticCallee() != fn || len(related) >= maxRelated {
+					continue
+				}
+				args := call.Common().Args
+				if index >= len(args) {
+					continue
+				}
+				related = append(related, analysis.RelatedInformation{
+					Pos:     call.Common().Pos(),
+					Message: fmt.Sprintf("channel passed in as %s here, by %s", param.Name(), caller.RelString(s.pass.Pkg)),
+				})
+				related = s.trace(args[index], seen, related) // Where the caller got it, listed once
+			}
+		}
+	}
+	return related
+}
+
+// Gets the struct field of the address.
+func fieldOf(addr *ssa.FieldAddr) *types.Var {
+	return addr.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct).Field(addr.Field)
+}
@@ -0,0 +1,329 @@
+package channelcheck
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Severity of the findings of a rule.
+const (
+	SeverityWarning = "warning" // A heuristic. The code may be fine.
+	SeverityError   = "error"   // A guaranteed bug on some path.
+)
+
+/*
+Rule describes a kind of finding. The ID is stable and set as the Category of every diagnostic, so
+suppressions, baselines and dashboards can key on it. An analyzer may report more than one rule.
+*/
+type Rule struct {
+	ID          string // Stable identifier, like "CC001"
+	Name        string // Readable identifier, like "blocking-send"
+	Analyzer    string // Name of the analyzer reporting it
+	Severity    string
+	Summary     string
+	Explanation string // Rationale and fix guidance, printed by 'channellint -explain'
+}
+
+var (
+	ruleBlockingSend = &Rule{
+		ID:       "CC001",
+		Name:     "blocking-send",
+		Analyzer: "blocking_send",
+		Severity: SeverityWarning,
+		Summary:  "Channel send without a default, timeout or cancellation case",
+		Explanation: `A send blocks until a receiver takes the value, or until there's room in the buffer. If
+the receiver stops, for example because it returned on an error, the sender is stuck forever.
+
+Put the send in a select with a way out:
+- a 'case <-ctx.Done():' that gives up once the work is cancelled
+- a 'case <-time.After(d):' or timer case that gives up after a while
+- a 'default:' case if dropping the value is acceptable
+
+A ctx.Done() case only helps if the context can be cancelled. One from context.Background or
+context.TODO never is, its Done channel is nil, so the send is reported with a message saying so.
+
+When a context is in scope, the suggested fix wraps the send in a select on ctx.Done().`,
+	}
+
+	ruleUnbufferedMake = &Rule{
+		ID:       "CC002",
+		Name:     "unbuffered-make",
+		Analyzer: "unbuffered_make",
+		Severity: SeverityWarning,
+		Summary:  "Channel created without a buffer size",
+		Explanation: `Every send on an unbuffered channel waits for a receiver. That's a synchronization point
+which is easy to get wrong, like a goroutine sending its result after the caller stopped waiting.
+
+Give the channel a buffer, usually one slot per sending goroutine so none of them waits. The
+suggested fix does that when the goroutines can be counted, and uses the configured default size
+otherwise. Keep the channel unbuffered and suppress the finding if the handoff is intended.`,
+	}
+
+	ruleBufferOverMax = &Rule{
+		ID:       "CC003",
+		Name:     "buffer-over-max",
+		Analyzer: "buffer_size",
+		Severity: SeverityWarning,
+		Summary:  "Channel buffer larger than the configured maximum",
+		Explanation: `Large buffers hide slow consumers until the buffer fills up, and hold on to the memory
+of every queued value.
+
+Lower the buffer size, or raise the limit (CheckBufferAmount / -bufferMax) if the size is intended.`,
+	}
+
+	ruleZeroBuffer = &Rule{
+		ID:       "CC004",
+		Name:     "zero-buffer",
+		Analyzer: "buffer_size",
+		Severity: SeverityWarning,
+		Summary:  "Channel buffer size explicitly set to 0",
+		Explanation: `make(chan T, 0) is an unbuffered channel, which is easy to mistake for a buffered one
+when reading the code.
+
+Use a buffer of at least 1, or write make(chan T) if an unbuffered channel is intended.`,
+	}
+
+	ruleDynamicBuffer = &Rule{
+		ID:       "CC005",
+		Name:     "dynamic-buffer",
+		Analyzer: "dynamic_buffer",
+		Severity: SeverityWarning,
+		Summary:  "Channel buffer size only known at runtime",
+		Explanation: `A buffer sized by a variable can't be checked against the maximum, and may be 0 or huge
+depending on the input.
+
+Use a constant size, or bound the variable before creating the channel and suppress the finding.`,
+	}
+
+	ruleBlockingRecv = &Rule{
+		ID:       "CC006",
+		Name:     "blocking-receive",
+		Analyzer: "blocking_receive",
+		Severity: SeverityWarning,
+		Summary:  "Channel receive without a default, timeout or cancellation case",
+		Explanation: `A receive blocks until a value is sent or the channel is closed. If the sender never
+sends, the receiver is stuck forever.
+
+Put the receive in a select with a 'case <-ctx.Done():', a timer case or a 'default:' case.
+Receives from timers and from ctx.Done() always finish and aren't reported.`,
+	}
+
+	ruleBlockingRange = &Rule{
+		ID:       "CC007",
+		Name:     "blocking-range",
+		Analyzer: "blocking_receive",
+		Severity: SeverityWarning,
+		Summary:  "Range over a channel that blocks until the channel is closed",
+		Explanation: `'for v := range ch' only ends once the channel is closed. If the sender forgets to close
+it, or returns early, the loop never ends.
+
+Make sure every sender path closes the channel, or loop over a select with a cancellation or
+timeout case instead.`,
+	}
+
+	ruleSelfDeadlock = &Rule{
+		ID:       "CC008",
+		Name:     "self-deadlock",
+		Analyzer: "self_deadlock",
+		Severity: SeverityError,
+		Summary:  "Send on an unbuffered channel before any goroutine can receive from it",
+		Explanation: `The send waits for a receiver, but the only code that could receive runs after the send in
+the same goroutine. The goroutine deadlocks.
+
+Start the receiving goroutine before sending, or give the channel a buffer.`,
+	}
+
+	ruleDoubleClose = &Rule{
+		ID:       "CC009",
+		Name:     "double-close",
+		Analyzer: "double_close",
+		Severity: SeverityError,
+		Summary:  "Channel that may be closed more than once",
+		Explanation: `Closing a closed channel panics. This happens when close is called in a loop, both in a
+defer and in the body, or from several goroutines. A close guarded by a flag that's set right after
+it, like 'if !closed { close(c); closed = true }', isn't reported outside of goroutines.
+
+Close the channel in a single place, usually the only sender, or guard the close with a sync.Once.`,
+	}
+
+	ruleSendAfterClose = &Rule{
+		ID:       "CC010",
+		Name:     "send-after-close",
+		Analyzer: "send_after_close",
+		Severity: SeverityError,
+		Summary:  "Send on a channel that may already be closed",
+		Explanation: `Sending on a closed channel panics, whether or not the send is in a select.
+
+Only close a channel after the last send, from the sending side. If several goroutines send, wait
+for all of them, for example with a sync.WaitGroup, before closing.`,
+	}
+
+	ruleGoroutineLeak = &Rule{
+		ID:       "CC011",
+		Name:     "goroutine-leak",
+		Analyzer: "goroutine_leak",
+		Severity: SeverityWarning,
+		Summary:  "Goroutines stranded sending on a channel that is no longer received from",
+		Explanation: `Goroutines send their results on a channel, but the function can return before receiving
+all of them, like on the first error or on a timeout. The remaining goroutines block forever on
+their send and are never freed.
+
+Give the channel a buffer with one slot per goroutine, or have the goroutines select on a
+cancellation channel that's closed when the function returns.`,
+	}
+
+	ruleMissingTypes = &Rule{
+		ID:       "CC012",
+		Name:     "missing-type-info",
+		Severity: SeverityWarning,
+		Summary:  "The package doesn't type check, so the checks fall back to the syntax",
+		Explanation: `The package has type errors, so its type information is incomplete. Only the syntax-only
+checks ran and the results are less precise. Some findings may be missing, others may be false
+positives.
+
+Fix the errors reported by the compiler, and the full checks run again.`,
+	}
+
+	ruleLockedChannelOp = &Rule{
+		ID:       "CC013",
+		Name:     "locked-channel-op",
+		Analyzer: "locked_channel_op",
+		Severity: SeverityWarning,
+		Summary:  "Channel operation that blocks while a mutex is held",
+		Explanation: `A send, receive or select without a default waits for another goroutine. If that goroutine
+needs the same mutex before it gets to the channel, neither can continue. Even without a deadlock,
+every other user of the mutex waits as long as the channel does.
+
+Release the mutex before the channel operation, for example by copying what's needed while it's
+held. If the operation has to happen under the lock, make it non-blocking with a select and a
+default case.`,
+	}
+
+	ruleSelectBreak = &Rule{
+		ID:       "CC014",
+		Name:     "select-break",
+		Analyzer: "select_break",
+		Severity: SeverityWarning,
+		Summary:  "Break in a select case that only leaves the select, not the loop around it",
+		Explanation: `In 'for { select { case <-done: break } }' the break ends the select statement, so the
+loop starts over and never stops. This is almost never what was meant.
+
+Label the loop and use 'break label', or return from the function. The suggested fix adds the
+label. If leaving the select early was intended, suppress the finding.`,
+	}
+
+	ruleBusyLoop = &Rule{
+		ID:       "CC015",
+		Name:     "busy-loop",
+		Analyzer: "busy_loop",
+		Severity: SeverityWarning,
+		Summary:  "Select with a default that spins in a loop without a condition",
+		Explanation: `The default case runs right away whenever no other case is ready. In 'for { select { ...
+default: } }' the loop then starts over immediately and burns a CPU until a case is ready. The
+default doesn't make the sends and receives of the select safe either, since they're retried until
+they happen, so they're reported as blocking too.
+
+Drop the default case so the select waits, or wait in it: sleep, block on another channel, or return
+or break out of the loop.`,
+	}
+
+	ruleTimeAfterInLoop = &Rule{
+		ID:       "CC016",
+		Name:     "time-after-in-loop",
+		Analyzer: "timer_leak",
+		Severity: SeverityWarning,
+		Summary:  "time.After called in a loop, creating a timer on every iteration",
+		Explanation: `Every call to time.After creates a timer that can't be stopped. Before Go 1.23, the timer
+stays in the runtime until it fires, so a loop that goes around quickly with a long timeout piles
+up timers. Since Go 1.23 they're garbage collected, but every iteration still allocates one.
+
+Create a time.Timer before the loop, Reset it on every iteration and Stop it when done. If the
+timeout is meant to cover the whole loop, call time.After once before the loop.`,
+	}
+
+	ruleTimeTickLeak = &Rule{
+		ID:       "CC017",
+		Name:     "time-tick-leak",
+		Analyzer: "timer_leak",
+		Severity: SeverityWarning,
+		Summary:  "time.Tick outside of main, leaking its ticker before Go 1.23",
+		Explanation: `time.Tick returns the channel of a ticker that can't be stopped. Before Go 1.23, the ticker
+runs forever, so calling it in a function that returns leaks it. In main or a package level
+variable it lives as long as the program anyway. Modules on Go 1.23 or later aren't reported, since
+unreferenced tickers are garbage collected there.
+
+Use time.NewTicker and 'defer ticker.Stop()', or raise the go version of the module.`,
+	}
+
+	ruleTimerStop = &Rule{
+		ID:       "CC018",
+		Name:     "unstopped-timer",
+		Analyzer: "unstopped_timer",
+		Severity: SeverityWarning,
+		Summary:  "Ticker or timer in a select case that isn't stopped on some return path",
+		Explanation: `A ticker from time.NewTicker keeps firing until it's stopped, and a timer from time.NewTimer
+holds its resources until it fires or is stopped. When the function returns on another select case,
+like a cancellation, nothing stops them anymore.
+
+Call 'defer ticker.Stop()' right after creating it, so every return path stops it.`,
+	}
+)
+
+// Rules holds every rule, ordered by ID.
+var Rules = []*Rule{
+	ruleBlockingSend,
+	ruleUnbufferedMake,
+	ruleBufferOverMax,
+	ruleZeroBuffer,
+	ruleDynamicBuffer,
+	ruleBlockingRecv,
+	ruleBlockingRange,
+	ruleSelfDeadlock,
+	ruleDoubleClose,
+	ruleSendAfterClose,
+	ruleGoroutineLeak,
+	ruleMissingTypes,
+	ruleLockedChannelOp,
+	ruleSelectBreak,
+	ruleBusyLoop,
+	ruleTimeAfterInLoop,
+	ruleTimeTickLeak,
+	ruleTimerStop,
+}
+
+// LookupRule finds a rule by its ID or name, ignoring case.
+func LookupRule(idOrName string) (*Rule, bool) {
+	for _, rule := range Rules {
+		if strings.EqualFold(rule.ID, idOrName) || strings.EqualFold(rule.Name, idOrName) {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+// Reports a finding of the rule on the node.
+func report(pass *analysis.Pass, rule *Rule, node analysis.Range, format string, args ...any) {
+	reportDiagnostic(pass, rule, analysis.Diagnostic{Pos: node.Pos(), End: node.End(), Message: fmt.Sprintf(format, args...)})
+}
+
+/*
+Reports the diagnostic as a finding of the rule. The category holds the rule ID, and the message starts
+with it, like "[CC001] ...", since the text output of the drivers and golangci-lint only print the
+message. Rules with the error severity follow it with "error: ", which keeps them apart.
+*/
+func reportDiagnostic(pass *analysis.Pass, rule *Rule, diag analysis.Diagnostic) {
+	diag.Category = rule.ID
+	diag.Message = messagePrefix(rule) + diag.Message
+	pass.Report(diag)
+}
+
+// Gets the start of the messages of the rule, like "[CC008] error: ".
+func messagePrefix(rule *Rule) string {
+	prefix := "[" + rule.ID + "] "
+	if rule.Severity == SeverityError {
+		prefix += SeverityError + ": "
+	}
+	return prefix
+}
@@ -0,0 +1,146 @@
+package channelcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+/*
+Breaks that only leave the select.
+
+	for {
+		select {
+		case <-done:
+			break // Leaves the select, the loop keeps going
+		case v := <-values:
+			process(v)
+		}
+	}
+
+An unlabeled break inside a select case ends the select, not the loop around it. Breaks that belong
+to a loop, switch or select nested in the case are left alone. The fix labels the loop, or reuses
+its label, and breaks out of it.
+*/
+
+// Reports unlabeled breaks in the cases of a select directly inside a for loop.
+func (l *linter) runSelectBreak(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckSelectBreak {
+		return nil, nil
+	}
+	l.typesLoaded(pass)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeFilter := []ast.Node{(*ast.ForStmt)(nil), (*ast.RangeStmt)(nil)}
+	inspect.WithStack(nodeFilter, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		var body *ast.BlockStmt
+		switch n := node.(type) {
+		case *ast.ForStmt:
+			body = n.Body
+		case *ast.RangeStmt:
+			body = n.Body
+		}
+
+		for _, stmt := range body.List {
+			sel, ok := stmt.(*ast.SelectStmt)
+			if !ok {
+				continue
+			}
+			for _, clause := range sel.Body.List {
+				for _, brk := range selectBreaks(clause.(*ast.CommClause)) {
+					reportDiagnostic(pass, ruleSelectBreak, analysis.Diagnostic{
+						Pos:            brk.Pos(),
+						End:            brk.End(),
+						Message:        "break inside select only leaves the select, not the enclosing loop - consider a labeled break",
+						SuggestedFixes: labeledBreakFix(pass, brk, stack),
+					})
+				}
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// Gets the unlabeled breaks of the select case, without the ones of nested loops, switches and selects.
+func selectBreaks(clause *ast.CommClause) []*ast.BranchStmt {
+	var breaks []*ast.BranchStmt
+	for _, stmt := range clause.Body {
+		ast.Inspect(stmt, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+				return false
+			case *ast.BranchStmt:
+				if n.Tok == token.BREAK && n.Label == nil {
+					breaks = append(breaks, n)
+				}
+			}
+			return true
+		})
+	}
+	return breaks
+}
+
+/*
+Turns the break into a break of the loop at the top of the stack. A loop without a label gets one,
+named so it doesn't clash with the other labels of the function.
+*/
+func labeledBreakFix(pass *analysis.Pass, brk *ast.BranchStmt, stack []ast.Node) []analysis.SuggestedFix {
+	loop := stack[len(stack)-1]
+	if len(stack) >= 2 {
+		if labeled, ok := stack[len(stack)-2].(*ast.LabeledStmt); ok && labeled.Stmt == loop {
+			return []analysis.SuggestedFix{{
+				Message: fmt.Sprintf("Break out of the loop labeled %s", labeled.Label.Name),
+				TextEdits: []analysis.TextEdit{
+					{Pos: brk.End(), End: brk.End(), NewText: []byte(" " + labeled.Label.Name)},
+				},
+			}}
+		}
+	}
+
+	// Labels are scoped to the function, so look at every label in the innermost one.
+	var fnBody *ast.BlockStmt
+	for i := len(stack) - 1; i >= 0 && fnBody == nil; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncDecl:
+			fnBody = fn.Body
+		case *ast.FuncLit:
+			fnBody = fn.Body
+		}
+	}
+	if fnBody == nil {
+		return nil
+	}
+	labels := make(map[string]bool)
+	ast.Inspect(fnBody, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.LabeledStmt:
+			labels[n.Label.Name] = true
+		}
+		return true
+	})
+	label := "loop"
+	for i := 2; labels[label]; i++ {
+		label = "loop" + strconv.Itoa(i)
+	}
+
+	// Every break of the loop inserts the same label, so applying all the fixes labels the loop once.
+	indent := strings.Repeat("\t", pass.Fset.Position(loop.Pos()).Column-1)
+	return []analysis.SuggestedFix{{
+		Message: fmt.Sprintf("Label the loop %s and break out of it", label),
+		TextEdits: []analysis.TextEdit{
+			{Pos: loop.Pos(), End: loop.Pos(), NewText: []byte(label + ":\n" + indent)},
+			{Pos: brk.End(), End: brk.End(), NewText: []byte(" " + label)},
+		},
+	}}
+}
@@ -0,0 +1,202 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"reflect"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+/*
+Suppression directives, so that every driver honors the same comments:
+
+	//nolint:channelcheck
+	//nolint:blocking_send,double_close // Rules can be listed by name
+	//channelcheck:ignore CC001 the consumer never stops
+
+Rules are named by analyzer (blocking_send), rule ID (CC001) or rule name (blocking-send). A plain
+'//nolint' suppresses everything. The reason of 'channelcheck:ignore' is required, and the directive
+is ignored without one. 'all' can be used instead of a rule name.
+
+Where the directive is placed decides what it covers:
+- at the end of a line, that line
+- on a line of its own, that line and the next one
+- in the doc comment of a function, the whole function
+- above the package clause, the whole file
+
+golangci-lint applies nolint directives itself and reports the ones that didn't suppress anything
+when nolintlint is enabled, so the plugin leaves them to it and only honors 'channelcheck:ignore'.
+*/
+
+// A range of the package where some rules are suppressed.
+type suppression struct {
+	pos, end token.Pos
+	rules    []string // nil means every rule
+	nolint   bool     // From a nolint directive instead of channelcheck:ignore
+}
+
+type suppressions []suppression
+
+// Collects the suppression directives of the package.
+var suppressAnalyzer = &analysis.Analyzer{
+	Name:             "channelsuppress",
+	Doc:              "collects nolint and channelcheck:ignore directives",
+	Run:              runSuppress,
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf(suppressions(nil)),
+}
+
+func runSuppress(pass *analysis.Pass) (interface{}, error) {
+	var result suppressions
+	for _, file := range pass.Files {
+		tokenFile := pass.Fset.File(file.FileStart)
+		if tokenFile == nil {
+			continue
+		}
+
+		// Comments in the doc of a function cover the function.
+		funcDocs := make(map[*ast.CommentGroup]*ast.FuncDecl)
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Doc != nil {
+				funcDocs[fn.Doc] = fn
+			}
+		}
+		codeLines := linesWithCode(pass.Fset, file)
+
+		for _, group := range file.Comments {
+			for _, comment := range group.List {
+				rules, ok := parseDirective(comment.Text)
+				if !ok {
+					continue
+				}
+
+				s := suppression{rules: rules, nolint: strings.HasPrefix(comment.Text, "//nolint")}
+				line := tokenFile.Line(comment.Pos())
+				switch {
+				case comment.End() < file.Package:
+					s.pos, s.end = file.FileStart, file.FileEnd
+				case funcDocs[group] != nil:
+					s.pos, s.end = funcDocs[group].Pos(), funcDocs[group].End()
+				case codeLines[line]:
+					s.pos, s.end = lineStart(tokenFile, line), lineStart(tokenFile, line+1)
+				default:
+					s.pos, s.end = lineStart(tokenFile, line), lineStart(tokenFile, line+2)
+				}
+				result = append(result, s)
+			}
+		}
+	}
+	return result, nil
+}
+
+/*
+Parses a directive, returning the rules it suppresses. A nil slice means every rule. Returns false if
+the comment isn't a directive for this linter.
+*/
+func parseDirective(text string) ([]string, bool) {
+	if !strings.HasPrefix(text, "//") {
+		return nil, false
+	}
+	text = strings.TrimPrefix(text, "//")
+
+	if rest, ok := strings.CutPrefix(text, "channelcheck:ignore "); ok {
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			return nil, false // No reason given
+		}
+		return directiveRules(strings.Split(fields[0], ","))
+	}
+
+	rest, ok := strings.CutPrefix(text, "nolint")
+	if !ok {
+		return nil, false
+	}
+	if rest == "" || rest[0] == ' ' {
+		return nil, true // Every linter
+	}
+	if rest[0] != ':' {
+		return nil, false // e.g. '//nolintfoo'
+	}
+	names, _, _ := strings.Cut(rest[1:], " ")
+	return directiveRules(strings.Split(names, ","))
+}
+
+// Maps the names of a directive to rules. The name of the linter and 'all' mean every rule.
+func directiveRules(names []string) ([]string, bool) {
+	var rules []string
+	for _, name := range names {
+		switch name = strings.TrimSpace(name); name {
+		case "channelcheck", "all":
+			return nil, true
+		case "":
+		default:
+			rules = append(rules, name)
+		}
+	}
+	return rules, len(rules) > 0
+}
+
+/*
+Checks if the diagnostic is suppressed. Directives can name the analyzer, the rule ID or the rule name.
+nolint directives are skipped when the driver applies them itself.
+*/
+func (s suppressions) suppressed(analyzer string, diag analysis.Diagnostic, skipNolint bool) bool {
+	names := []string{analyzer, diag.Category}
+	if rule, ok := LookupRule(diag.Category); ok {
+		names = append(names, rule.Name)
+	}
+
+	for _, suppression := range s {
+		if diag.Pos < suppression.pos || diag.Pos >= suppression.end || skipNolint && suppression.nolint {
+			continue
+		}
+		if suppression.rules == nil {
+			return true
+		}
+		for _, name := range names {
+			if slices.ContainsFunc(suppression.rules, func(rule string) bool { return strings.EqualFold(rule, name) }) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Wraps the run function of a rule so that its suppressed diagnostics are dropped.
+func withSuppressions(analyzer string, skipNolint bool, run func(*analysis.Pass) (interface{}, error)) func(*analysis.Pass) (interface{}, error) {
+	return func(pass *analysis.Pass) (interface{}, error) {
+		ignored := pass.ResultOf[suppressAnalyzer].(suppressions)
+		filtered := *pass
+		filtered.Report = func(diag analysis.Diagnostic) {
+			if !ignored.suppressed(analyzer, diag, skipNolint) {
+				pass.Report(diag)
+			}
+		}
+		return run(&filtered)
+	}
+}
+
+// Gets the lines where a node ends, which is every line that has code before a trailing comment.
+func linesWithCode(fset *token.FileSet, file *ast.File) map[int]bool {
+	lines := make(map[int]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node.(type) {
+		case nil, *ast.CommentGroup, *ast.Comment:
+			return false
+		}
+		lines[fset.Position(node.End()).Line] = true
+		return true
+	})
+	return lines
+}
+
+// Gets the position of the start of the line, or the end of the file past the last line.
+func lineStart(tokenFile *token.File, line int) token.Pos {
+	if line > tokenFile.LineCount() {
+		return token.Pos(tokenFile.Base() + tokenFile.Size())
+	}
+	return tokenFile.LineStart(line)
+}
@@ -0,0 +1,96 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+)
+
+/*
+Syntax-only fallback.
+
+Most checks rely on type information: to resolve 'make' and 'time', to know that a value is a channel
+or a context, and to build the SSA form. The analyzers run despite errors, so when the package doesn't
+type check, the type information is incomplete and the checks fall back to matching the syntax instead:
+- make(chan T, n) is only recognized with a literal channel type, and only literal sizes are evaluated
+- any '.Done()' call counts as a context, and '<-time.After(d)' and '<-x.C' as timers
+- ranging over a channel, dynamic buffer sizes and everything that needs SSA is skipped
+
+The first package analyzed this way gets a diagnostic, so it's clear which mode produced the findings.
+*/
+
+// Checks if the package type checked without errors, so its type information is complete.
+func hasTypeInfo(pass *analysis.Pass) bool {
+	return pass.TypesInfo != nil && len(pass.TypeErrors) == 0
+}
+
+// Checks for type information, reporting once per linter instance when it's missing.
+func (l *linter) typesLoaded(pass *analysis.Pass) bool {
+	if hasTypeInfo(pass) {
+		return true
+	}
+	l.reducedPrecision.Do(func() {
+		if len(pass.Files) > 0 {
+			reportDiagnostic(pass, ruleMissingTypes, analysis.Diagnostic{
+				Pos:     pass.Files[0].Package,
+				Message: "the package doesn't type check, falling back to syntax-only checks with reduced precision",
+			})
+		}
+	})
+	return false
+}
+
+// Same as checkChannelCreation without type information.
+func channelCreationSyntax(node *ast.CallExpr) (bool, channelBuffer) {
+	if _, ok := ast.Unparen(node.Args[0]).(*ast.ChanType); !ok {
+		return false, channelBuffer{}
+	}
+	if len(node.Args) == 1 {
+		return true, channelBuffer{omitted: true}
+	}
+
+	lit, ok := ast.Unparen(node.Args[1]).(*ast.BasicLit)
+	if !ok || lit.Kind != token.INT {
+		return true, channelBuffer{dynamic: true} // Possibly a named constant, which can't be resolved.
+	}
+	bufferSize, exact := constant.Uint64Val(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
+	if !exact {
+		return true, channelBuffer{dynamic: true}
+	}
+	return true, channelBuffer{size: bufferSize}
+}
+
+// Same as isTimeReturnType without type information. Matches '<-time.After(d)' and '<-timer.C'.
+func timerRecvSyntax(pass *analysis.Pass, expr ast.Expr) bool {
+	recv, ok := ast.Unparen(expr).(*ast.UnaryExpr)
+	if !ok {
+		return false
+	}
+	if sel, ok := ast.Unparen(recv.X).(*ast.SelectorExpr); ok && sel.Sel.Name == "C" {
+		return true
+	}
+	return isTimeAfter(pass, ast.Unparen(recv.X))
+}
+
+/*
+Builds the SSA form only when the package has type information, since the builder needs it. The
+result is nil otherwise, and the rules that need it are skipped.
+*/
+var ssaAnalyzer = &analysis.Analyzer{
+	Name:             "channelssa",
+	Doc:              "builds the SSA form of packages that have type information",
+	Run:              runSSA,
+	RunDespiteErrors: true,
+	ResultType:       reflect.TypeOf(new(buildssa.SSA)),
+}
+
+func runSSA(pass *analysis.Pass) (interface{}, error) {
+	if !hasTypeInfo(pass) {
+		return (*buildssa.SSA)(nil), nil
+	}
+	return buildssa.Analyzer.Run(pass)
+}
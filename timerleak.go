@@ -0,0 +1,109 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/version"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+/*
+Timers that are created and never stopped.
+
+	for {
+		select {
+		case v := <-values:
+			process(v)
+		case <-time.After(time.Minute): // A new timer on every iteration
+			return
+		}
+	}
+
+time.After and time.Tick don't give access to the timer, so it can't be stopped. Before Go 1.23,
+such a timer stayed in the runtime until it fired, and a ticker from time.Tick forever. A time.After
+in a busy loop piles up timers, and a time.Tick in a function that returns leaks its ticker.
+
+Since Go 1.23, unreferenced timers and tickers are garbage collected. A time.Tick is fine then, and a
+time.After in a loop only costs an allocation per iteration. The Go version of the module decides
+which behavior applies, falling back to the version of the package.
+*/
+
+// First Go version where the runtime collects timers that are no longer referenced.
+const timerGCVersion = "go1.23"
+
+// Checks if the package is built with a Go version that garbage collects unreferenced timers.
+func collectsTimers(pass *analysis.Pass) bool {
+	goVersion := ""
+	if pass.Module != nil && pass.Module.GoVersion != "" {
+		goVersion = "go" + pass.Module.GoVersion
+	} else if pass.Pkg != nil {
+		goVersion = pass.Pkg.GoVersion()
+	}
+	if !version.IsValid(goVersion) {
+		return false // Unknown, so assume the older runtime
+	}
+	return version.Compare(goVersion, timerGCVersion) >= 0
+}
+
+// Reports time.After in loops and time.Tick outside of main.
+func (l *linter) runTimerLeak(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckTimerLeaks {
+		return nil, nil
+	}
+	l.typesLoaded(pass)
+	collected := collectsTimers(pass)
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		call := node.(*ast.CallExpr)
+		switch {
+		case isTimeAfter(pass, call):
+			if !inLoop(stack) {
+				return true
+			}
+			if collected {
+				report(pass, ruleTimeAfterInLoop, call, "time.After in a loop allocates a new timer on every iteration - consider a time.Timer that is reset %q", render(pass.Fset, call))
+			} else {
+				report(pass, ruleTimeAfterInLoop, call, "time.After in a loop creates a timer on every iteration that isn't freed until it fires - consider a time.Timer that is reset and stopped %q", render(pass.Fset, call))
+			}
+
+		case isTimeCall(pass, call, "Tick"):
+			if !collected && !inMain(pass, stack) {
+				report(pass, ruleTimeTickLeak, call, "time.Tick outside of main leaks its ticker, it's never stopped - consider time.NewTicker with a deferred Stop %q", render(pass.Fset, call))
+			}
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// Checks if the innermost loop or function around the last node of the stack is a loop.
+func inLoop(stack []ast.Node) bool {
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return true
+		case *ast.FuncLit, *ast.FuncDecl:
+			return false
+		}
+	}
+	return false
+}
+
+/*
+Checks if the last node of the stack runs for the lifetime of the program: in the main function of
+package main, or in the initializer of a package level variable.
+*/
+func inMain(pass *analysis.Pass, stack []ast.Node) bool {
+	for _, node := range stack {
+		if fn, ok := node.(*ast.FuncDecl); ok {
+			return pass.Pkg.Name() == "main" && fn.Recv == nil && fn.Name.Name == "main"
+		}
+	}
+	return true
+}
@@ -0,0 +1,319 @@
+package channelcheck
+
+import (
+	"go/token"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+/*
+Dataflow based resolution of timer channels.
+
+The AST only tells us the type of a received value. Instead, we look at the SSA form of the function
+and follow the received channel back to where it was created. This handles channels that are stored in
+variables first, pulled out of an interface with a type assertion or read from the 'C' field of a
+Timer or Ticker. Calls to functions that return a timer or ctx.Done(), in this package or another one,
+are resolved through the facts in facts.go. The tracing of returned channels there classifies calls,
+contexts and timer fields with the same helpers as this file, so both agree.
+*/
+
+// The origin of a channel that's received from in a select case.
+type chanOrigin int
+
+const (
+	originUnknown     chanOrigin = iota // Can't be traced to a timer, such as parameters, struct fields and make(chan time.Time)
+	originTimer                         // time.After, time.Tick, time.NewTimer(...).C or time.NewTicker(...).C
+	originOther                         // Traced to a channel that never delivers, such as nil or the 'C' field of an AfterFunc timer
+	originCancel                        // ctx.Done() on a context.Context
+	originNeverCancel                   // ctx.Done() on a context from context.Background or context.TODO, which is nil
+)
+
+/*
+Collects the SSA value of every received channel in the package, keyed by the position of the '<-'
+token. This is the same position as 'ast.UnaryExpr.OpPos' so the AST walk can look the channel up.
+*/
+func recvChannels(ssaInfo *buildssa.SSA) map[token.Pos]ssa.Value {
+	chans := make(map[token.Pos]ssa.Value)
+	for _, fn := range ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				switch instr := instr.(type) {
+				case *ssa.Select:
+					for _, state := range instr.States {
+						if state.Dir == types.RecvOnly {
+							chans[state.Pos] = state.Chan
+						}
+					}
+				case *ssa.UnOp: // A select with a single case is turned into a plain receive.
+					if instr.Op == token.ARROW {
+						chans[instr.Pos()] = instr.X
+					}
+				}
+			}
+		}
+	}
+	return chans
+}
+
+// Follows the channel value back to where it came from.
+func (f *channelFacts) traceChannel(value ssa.Value, seen map[ssa.Value]bool) chanOrigin {
+	if seen[value] {
+		return originTimer // Already visited, e.g. a loop in the phi nodes. The other edges decide.
+	}
+	seen[value] = true
+
+	switch v := value.(type) {
+	case *ssa.Call:
+		if isContextDoneCall(v.Call) {
+			if neverCancelled(contextOf(v.Call), make(map[ssa.Value]bool)) {
+				return originNeverCancel
+			}
+			return originCancel
+		}
+		return f.callOrigin(v.Call.StaticCallee())
+
+	case *ssa.UnOp: // Load of a variable or a field, like 'timer.C'
+		if v.Op != token.MUL {
+			return originUnknown
+		}
+		switch addr := v.X.(type) {
+		case *ssa.FieldAddr:
+			var constructor *types.Func
+			if call, ok := addr.X.(*ssa.Call); ok {
+				constructor = funcObject(call.Call.StaticCallee())
+			}
+			field := addr.X.Type().Underlying().(*types.Pointer).Elem().Underlying().(*types.Struct).Field(addr.Field)
+			return timerFieldOrigin(addr.X.Type(), field.Name(), constructor)
+		case *ssa.Alloc:
+			return f.traceStores(addr, seen)
+		}
+		return originUnknown
+
+	case *ssa.Phi:
+		origins := make([]chanOrigin, 0, len(v.Edges))
+		for _, edge := range v.Edges {
+			origins = append(origins, f.traceChannel(edge, seen))
+		}
+		return mergeOrigins(origins)
+
+	case *ssa.ChangeType:
+		return f.traceChannel(v.X, seen)
+	case *ssa.MakeInterface:
+		return f.traceChannel(v.X, seen)
+	case *ssa.ChangeInterface:
+		return f.traceChannel(v.X, seen)
+	case *ssa.TypeAssert:
+		return f.traceChannel(v.X, seen)
+	case *ssa.Extract: // v, ok := x.(<-chan time.Time)
+		if assert, ok := v.Tuple.(*ssa.TypeAssert); ok && v.Index == 0 {
+			return f.traceChannel(assert.X, seen)
+		}
+		return originUnknown
+
+	case *ssa.Const: // A nil channel blocks forever
+		return originOther
+	}
+
+	return originUnknown
+}
+
+/*
+Handles reads of a field on a value of the given type, like the 'C' field of a time.Timer or
+time.Ticker. Timers from time.AfterFunc are the exception: their 'C' field is nil, so receiving from it
+blocks forever. The constructor is the function whose result the field is read from, if any.
+*/
+func timerFieldOrigin(typ types.Type, field string, constructor *types.Func) chanOrigin {
+	if typ == nil || field != "C" {
+		return originUnknown
+	}
+	pointer, ok := typ.Underlying().(*types.Pointer)
+	if !ok {
+		return originUnknown
+	}
+	named, ok := pointer.Elem().(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "time" {
+		return originUnknown
+	}
+	if named.Obj().Name() != "Timer" && named.Obj().Name() != "Ticker" {
+		return originUnknown
+	}
+
+	if isTimeFunc(constructor, "AfterFunc") {
+		return originOther
+	}
+	return originTimer
+}
+
+/*
+Gets the origin of the channel returned by a call to the function: time.After and time.Tick are
+timers, and other functions are looked up in the origins from facts.go.
+*/
+func calleeOrigin(fn *types.Func, origins funcOrigins) chanOrigin {
+	if fn == nil {
+		return originUnknown
+	}
+	fn = fn.Origin() // Instantiation of a generic function
+	if isTimeFunc(fn, "After", "Tick") {
+		return originTimer
+	}
+	return origins[fn]
+}
+
+// A local variable that had its address taken. Every value stored into it must be a timer.
+func (f *channelFacts) traceStores(alloc *ssa.Alloc, seen map[ssa.Value]bool) chanOrigin {
+	var origins []chanOrigin
+	for _, ref := range *alloc.Referrers() {
+		if store, ok := ref.(*ssa.Store); ok && store.Addr == alloc {
+			origins = append(origins, f.traceChannel(store.Val, seen))
+		}
+	}
+	return mergeOrigins(origins)
+}
+
+/*
+Merges the origins of several possible values. A channel that never delivers wins; otherwise, a single
+unknown value makes the whole thing unknown. A mix of timers and contexts is reported as a timer since
+either one fires eventually. A context that's never cancelled only keeps its own origin if every value
+is one, since it's a nil channel like any other.
+*/
+func mergeOrigins(origins []chanOrigin) chanOrigin {
+	if len(origins) == 0 {
+		return originUnknown
+	}
+
+	merged := origins[0]
+	for _, origin := range origins {
+		if origin == originNeverCancel && merged == originNeverCancel {
+			continue
+		}
+		if origin == originOther || origin == originNeverCancel || merged == originNeverCancel {
+			return originOther
+		}
+		if origin == originUnknown || merged == originUnknown {
+			merged = originUnknown
+		} else if origin != merged {
+			merged = originTimer
+		}
+	}
+	return merged
+}
+
+/*
+Checks if the call is 'Done()' on a context.Context. Either through the interface or directly on a
+concrete type that implements it.
+*/
+func isContextDoneCall(call ssa.CallCommon) bool {
+	if call.IsInvoke() {
+		return call.Method.Name() == "Done" && isContextType(call.Value.Type())
+	}
+
+	fn := call.StaticCallee()
+	if fn == nil || fn.Signature.Recv() == nil || fn.Name() != "Done" {
+		return false
+	}
+	return isContextType(fn.Signature.Recv().Type())
+}
+
+// Gets the context that Done is called on.
+func contextOf(call ssa.CallCommon) ssa.Value {
+	if call.IsInvoke() {
+		return call.Value
+	}
+	return call.Args[0]
+}
+
+/*
+Checks if the context can never be cancelled: it comes from context.Background or context.TODO,
+possibly wrapped by context.WithValue or context.WithoutCancel. Their Done returns nil, so a select
+case on it never fires. Parameters, fields and anything else that can't be followed may be
+cancelled.
+*/
+func neverCancelled(value ssa.Value, seen map[ssa.Value]bool) bool {
+	if seen[value] {
+		return true // A loop in the phi nodes. The other edges decide.
+	}
+	seen[value] = true
+
+	switch v := value.(type) {
+	case *ssa.Call:
+		never, fromParent := contextNeverCancelled(funcObject(v.Call.StaticCallee()))
+		if fromParent {
+			return neverCancelled(v.Call.Args[0], seen)
+		}
+		return never
+
+	case *ssa.UnOp:
+		alloc, ok := v.X.(*ssa.Alloc)
+		if v.Op != token.MUL || !ok {
+			return false
+		}
+		stored := false
+		for _, ref := range *alloc.Referrers() {
+			if store, ok := ref.(*ssa.Store); ok && store.Addr == alloc {
+				if !neverCancelled(store.Val, seen) {
+					return false
+				}
+				stored = true
+			}
+		}
+		return stored
+
+	case *ssa.Phi:
+		for _, edge := range v.Edges {
+			if !neverCancelled(edge, seen) {
+				return false
+			}
+		}
+		return len(v.Edges) > 0
+
+	case *ssa.MakeInterface:
+		return neverCancelled(v.X, seen)
+	case *ssa.ChangeInterface:
+		return neverCancelled(v.X, seen)
+	case *ssa.ChangeType:
+		return neverCancelled(v.X, seen)
+	case *ssa.TypeAssert:
+		return neverCancelled(v.X, seen)
+	}
+	return false
+}
+
+/*
+Checks if the context returned by the function can never be cancelled. Returns fromParent when it's
+the same as for the context passed in as the first argument, like for context.WithValue.
+*/
+func contextNeverCancelled(fn *types.Func) (never, fromParent bool) {
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "context" || fn.Signature().Recv() != nil {
+		return false, false
+	}
+	switch fn.Name() {
+	case "Background", "TODO", "WithoutCancel":
+		return true, false
+	case "WithValue":
+		return false, true
+	}
+	return false, false
+}
+
+// Checks if the function is one of the given package level functions from the 'time' package.
+func isTimeFunc(fn *types.Func, names ...string) bool {
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "time" || fn.Signature().Recv() != nil {
+		return false
+	}
+	return slices.Contains(names, fn.Name())
+}
+
+// Gets the declared function of an SSA function, or nil for function literals and wrappers.
+func funcObject(fn *ssa.Function) *types.Func {
+	if fn == nil {
+		return nil
+	}
+	if fn.Origin() != nil {
+		fn = fn.Origin() // Instantiation of a generic function
+	}
+	obj, _ := fn.Object().(*types.Func)
+	return obj
+}
@@ -0,0 +1,218 @@
+package channelcheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/buildssa"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/ssa"
+)
+
+/*
+Tickers and timers that are never stopped.
+
+	ticker := time.NewTicker(time.Second)
+	for {
+		select {
+		case <-ticker.C:
+			poll()
+		case <-done:
+			return // The ticker keeps running
+		}
+	}
+
+A timer or ticker in a select case is accepted as a way out of the select, see findNodeTimeout. It
+keeps running until Stop is called though, and a ticker never stops on its own. Every path from
+time.NewTicker or time.NewTimer to a return must call Stop or defer it.
+
+Only timers that stay in the function are checked, the same way as channels in leak.go. A timer
+that's returned, stored or passed to another function may be stopped there. Function literals that
+are called or deferred count as a Stop when they stop it.
+*/
+
+// Gets the Stop method of the timer or ticker if the call stops one, or the constructor if it creates one.
+func timeFunc(call *ssa.CallCommon) string {
+	callee := call.StaticCallee()
+	if callee == nil {
+		return ""
+	}
+	fn, ok := callee.Object().(*types.Func)
+	if !ok {
+		return ""
+	}
+	switch name := fn.FullName(); name {
+	case "time.NewTicker", "time.NewTimer", "(*time.Ticker).Stop", "(*time.Timer).Stop", "(*time.Ticker).Reset", "(*time.Timer).Reset":
+		return name
+	}
+	return ""
+}
+
+func isStop(call *ssa.CallCommon, holders map[ssa.Value]bool) bool {
+	name := timeFunc(call)
+	return (name == "(*time.Ticker).Stop" || name == "(*time.Timer).Stop") && holders[call.Args[0]]
+}
+
+/*
+Gets the instructions that stop the timer: calls and defers of Stop, and calls and defers of function
+literals stopping it. Returns false if the timer escapes or isn't used in a select, in which case it
+isn't checked.
+*/
+func timerStops(uses []ssa.Instruction, holders map[ssa.Value]bool) ([]ssa.Instruction, bool) {
+	var stops []ssa.Instruction
+	selected := false
+	for _, use := range uses {
+		switch use := use.(type) {
+		case *ssa.FieldAddr: // ticker.C
+			selected = selected || selectsOn(use)
+
+		case ssa.CallInstruction:
+			if isStop(use.Common(), holders) {
+				stops = append(stops, use)
+				continue
+			}
+			if _, ok := use.(*ssa.Go); ok || timeFunc(use.Common()) == "" {
+				return nil, false // Passed to another function
+			}
+
+		case *ssa.MakeClosure:
+			refs := *use.Referrers()
+			if len(refs) != 1 || !closureStops(use, holders) {
+				return nil, false
+			}
+			switch ref := refs[0].(type) {
+			case *ssa.Call, *ssa.Defer:
+				if ref.(ssa.CallInstruction).Common().Value == use {
+					stops = append(stops, ref)
+					continue
+				}
+			}
+			return nil, false
+
+		default:
+			return nil, false
+		}
+	}
+	return stops, selected
+}
+
+// Checks if the channel of the timer is received from in a select.
+func selectsOn(field *ssa.FieldAddr) bool {
+	for _, ref := range *field.Referrers() {
+		load, ok := ref.(*ssa.UnOp)
+		if !ok || load.Op != token.MUL {
+			continue
+		}
+		for _, ref := range *load.Referrers() {
+			if sel, ok := ref.(*ssa.Select); ok {
+				for _, state := range sel.States {
+					if state.Dir == types.RecvOnly && state.Chan == load {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Checks if the function literal calls Stop on the timer it captures.
+func closureStops(closure *ssa.MakeClosure, holders map[ssa.Value]bool) bool {
+	fn := closure.Fn.(*ssa.Function)
+	for i, binding := range closure.Bindings {
+		if !holders[binding] {
+			continue
+		}
+		freeVarHolders := make(map[ssa.Value]bool)
+		uses, ok := chanUses(fn.FreeVars[i], freeVarHolders)
+		if !ok {
+			return false
+		}
+		for _, use := range uses {
+			if call, ok := use.(ssa.CallInstruction); ok && isStop(call.Common(), freeVarHolders) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Checks if a return can be reached from the instruction without going through any of the stops.
+func returnsWithout(from ssa.Instruction, stops []ssa.Instruction) bool {
+	stopped := make(map[ssa.Instruction]bool)
+	for _, stop := range stops {
+		stopped[stop] = true
+	}
+
+	seen := make(map[*ssa.BasicBlock]bool)
+	var walk func(instrs []ssa.Instruction, succs []*ssa.BasicBlock) bool
+	walk = func(instrs []ssa.Instruction, succs []*ssa.BasicBlock) bool {
+		for _, instr := range instrs {
+			if stopped[instr] {
+				return false
+			}
+			if _, ok := instr.(*ssa.Return); ok {
+				return true
+			}
+		}
+		for _, succ := range succs {
+			if !seen[succ] {
+				seen[succ] = true
+				if walk(succ.Instrs, succ.Succs) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	block := from.Block()
+	return walk(block.Instrs[instrIndex(from)+1:], block.Succs)
+}
+
+// Reports tickers and timers in select cases that aren't stopped on some path to a return.
+func (l *linter) runTimerStop(pass *analysis.Pass) (interface{}, error) {
+	if !l.settings.CheckTimerStop {
+		return nil, nil
+	}
+	if !l.typesLoaded(pass) {
+		return nil, nil
+	}
+	ssaInfo := pass.ResultOf[ssaAnalyzer].(*buildssa.SSA)
+
+	calls := make(map[token.Pos]*ast.CallExpr)
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(node ast.Node) {
+		calls[node.(*ast.CallExpr).Lparen] = node.(*ast.CallExpr)
+	})
+
+	for _, fn := range ssaInfo.SrcFuncs {
+		for _, block := range fn.Blocks {
+			for _, instr := range block.Instrs {
+				call, ok := instr.(*ssa.Call)
+				if !ok {
+					continue
+				}
+				name := timeFunc(call.Common())
+				if name != "time.NewTicker" && name != "time.NewTimer" {
+					continue
+				}
+				holders := make(map[ssa.Value]bool)
+				uses, ok := chanUses(call, holders)
+				if !ok {
+					continue
+				}
+				stops, ok := timerStops(uses, holders)
+				if !ok || !returnsWithout(call, stops) {
+					continue
+				}
+				if node, ok := calls[call.Pos()]; ok {
+					report(pass, ruleTimerStop, node, "%s used in a select case is not stopped on some return path - consider deferring its Stop %q", name, render(pass.Fset, node))
+				}
+			}
+		}
+	}
+	return nil, nil
+}